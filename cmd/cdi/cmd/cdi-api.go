@@ -17,7 +17,12 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -25,6 +30,12 @@ import (
 	oci "github.com/opencontainers/runtime-spec/specs-go"
 	gen "github.com/opencontainers/runtime-tools/generate"
 	"tags.cncf.io/container-device-interface/pkg/cdi"
+	"tags.cncf.io/container-device-interface/pkg/cdi/diff"
+	"tags.cncf.io/container-device-interface/pkg/cdi/lint"
+	"tags.cncf.io/container-device-interface/pkg/cdi/metrics"
+	"tags.cncf.io/container-device-interface/pkg/cdi/policy"
+	"tags.cncf.io/container-device-interface/pkg/parser"
+	specs "tags.cncf.io/container-device-interface/specs-go"
 )
 
 func cdiListVendors() {
@@ -137,29 +148,26 @@ func cdiShowSpecDirs() {
 	}
 }
 
-func cdiInjectDevices(format string, ociSpec *oci.Spec, patterns []string) error {
+func cdiInjectDevices(format string, rootless bool, hostRoot string, ociSpec *oci.Spec, patterns []string) error {
 	var (
 		registry = cdi.GetRegistry()
-		matches  = map[string]struct{}{}
-		devices  = []string{}
+		options  []cdi.Option
 	)
 
-	for _, device := range registry.DeviceDB().ListDevices() {
-		for _, glob := range patterns {
-			match, err := filepath.Match(glob, device)
-			if err != nil {
-				return fmt.Errorf("failed to match pattern %q against %q: %w",
-					glob, device, err)
-			}
-			if match {
-				matches[device] = struct{}{}
-			}
-		}
+	if rootless {
+		options = append(options, cdi.WithRootlessInjection(true))
 	}
-	for device := range matches {
-		devices = append(devices, device)
+	if hostRoot != "" {
+		options = append(options, cdi.WithHostRoot(hostRoot))
+	}
+	if err := registry.Configure(options...); err != nil {
+		return fmt.Errorf("failed to configure CDI registry: %w", err)
+	}
+
+	devices, err := expandDeviceGlobs(registry.DeviceDB().ListDevices(), patterns)
+	if err != nil {
+		return err
 	}
-	sort.Strings(devices)
 
 	unresolved, err := registry.InjectDevices(ociSpec, devices...)
 
@@ -179,6 +187,64 @@ func cdiInjectDevices(format string, ociSpec *oci.Spec, patterns []string) error
 	return nil
 }
 
+// expandDeviceGlobs expands glob patterns matched against full qualified
+// device names, for instance '*' or 'vendor.com/*'. Device name patterns
+// and qualified names are left for the registry to resolve. Globs without
+// any match are left in place for the registry to report as unresolved.
+func expandDeviceGlobs(known, patterns []string) ([]string, error) {
+	var devices []string
+
+	for _, glob := range patterns {
+		if cdi.IsDevicePattern(glob) || !strings.ContainsAny(glob, "*?[") {
+			devices = append(devices, glob)
+			continue
+		}
+
+		// '/' is not a separator in qualified names, so '*' matches it too
+		var matches []string
+		for _, device := range known {
+			match, err := path.Match(unslash(glob), unslash(device))
+			if err != nil {
+				return nil, fmt.Errorf("failed to match pattern %q against %q: %w",
+					glob, device, err)
+			}
+			if match {
+				matches = append(matches, device)
+			}
+		}
+		if len(matches) == 0 {
+			matches = []string{glob}
+		}
+		sort.Strings(matches)
+		devices = append(devices, matches...)
+	}
+
+	return devices, nil
+}
+
+// unslash replaces '/' so that it is matched like any other character.
+func unslash(s string) string {
+	return strings.ReplaceAll(s, "/", "\x00")
+}
+
+func cdiPrintMetrics(devices ...string) error {
+	var (
+		registry = cdi.GetRegistry()
+		m        = metrics.New()
+	)
+
+	if err := registry.Configure(cdi.WithMetrics(m)); err != nil {
+		return fmt.Errorf("failed to enable CDI metrics: %w", err)
+	}
+
+	if len(devices) > 0 {
+		// failures are reflected in the metrics
+		_, _ = registry.InjectDevices(&oci.Spec{}, devices...)
+	}
+
+	return m.WritePrometheus(os.Stdout)
+}
+
 func cdiResolveDevices(ociSpecFiles ...string) error {
 	var (
 		cache      *cdi.Cache
@@ -348,3 +414,334 @@ func cdiPrintRegistryErrors() {
 		}
 	}
 }
+
+func cdiSignSpecs(keyFile string, paths ...string) error {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read private key: %w", err)
+	}
+	key, err := cdi.ParsePrivateKey(data)
+	if err != nil {
+		return fmt.Errorf("invalid private key %q: %w", keyFile, err)
+	}
+
+	for _, path := range paths {
+		spec, err := cdi.ReadSpec(path, 0)
+		if err != nil {
+			return err
+		}
+		sig, err := cdi.SignSpec(spec.Spec, key)
+		if err != nil {
+			return fmt.Errorf("failed to sign CDI Spec %q: %w", path, err)
+		}
+		if err := os.WriteFile(cdi.SignatureFile(path), sig, 0644); err != nil {
+			return fmt.Errorf("failed to write CDI Spec signature: %w", err)
+		}
+		fmt.Printf("Signed CDI Spec %s\n", path)
+	}
+
+	return nil
+}
+
+func cdiVerifySpecs(keyDir string, paths ...string) bool {
+	keys, err := cdi.ReadSignatureKeys(keyDir)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return false
+	}
+
+	verified := true
+	for _, path := range paths {
+		err := func() error {
+			spec, err := cdi.ReadSpec(path, 0)
+			if err != nil {
+				return err
+			}
+			sig, err := os.ReadFile(cdi.SignatureFile(path))
+			if err != nil {
+				return fmt.Errorf("failed to read signature: %w", err)
+			}
+			return cdi.VerifySpec(spec.Spec, sig, keys)
+		}()
+		if err != nil {
+			fmt.Printf("%s: FAILED: %v\n", path, err)
+			verified = false
+			continue
+		}
+		fmt.Printf("%s: OK\n", path)
+	}
+
+	return verified
+}
+
+func cdiCheckPolicy(policyFile string, paths ...string) (bool, error) {
+	p, err := policy.Load(policyFile)
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		ok      = true
+		devices []string
+	)
+	for _, path := range paths {
+		spec, err := cdi.ReadSpec(path, 0)
+		if err != nil {
+			return false, err
+		}
+		for _, dev := range spec.Devices {
+			var (
+				name  = parser.QualifiedName(spec.GetVendor(), spec.GetClass(), dev.Name)
+				edits = &cdi.ContainerEdits{ContainerEdits: &specs.ContainerEdits{}}
+			)
+			edits.Append(&cdi.ContainerEdits{ContainerEdits: &spec.ContainerEdits})
+			edits.Append(&cdi.ContainerEdits{ContainerEdits: &dev.ContainerEdits})
+			devices = append(devices, name)
+
+			for _, v := range p.Check([]string{name}, edits) {
+				fmt.Printf("%s: %s: %s\n", path, name, v)
+				ok = false
+			}
+		}
+	}
+
+	// check the number of devices, as if all were injected together
+	for _, v := range p.Check(devices, nil) {
+		fmt.Printf("%s\n", v)
+		ok = false
+	}
+
+	if ok {
+		fmt.Printf("No CDI policy violations.\n")
+	}
+
+	return ok, nil
+}
+
+func cdiGenerateSpec(cfg *generateFlags, kind string, globs ...string) error {
+	vendor, class := cdi.ParseQualifier(kind)
+	if vendor == "" || class == "" {
+		return fmt.Errorf("invalid vendor/class %q", kind)
+	}
+
+	var mounts []*specs.Mount
+	for _, m := range cfg.mounts {
+		mount, err := parseMount(m)
+		if err != nil {
+			return err
+		}
+		mounts = append(mounts, mount)
+	}
+
+	raw, err := cdi.GenerateSpec(&cdi.GenerateConfig{
+		Vendor:      vendor,
+		Class:       class,
+		DeviceGlobs: globs,
+		Env:         cfg.env,
+		Mounts:      mounts,
+		HostRoot:    cfg.hostRoot,
+	})
+	if err != nil {
+		return err
+	}
+
+	if cfg.dryRun {
+		fmt.Printf("%s", marshalObject(0, raw, cfg.output))
+		return nil
+	}
+
+	name := cfg.name
+	if name == "" {
+		name = vendor + "-" + class
+	}
+	if err := cdi.GetRegistry().SpecDB().WriteSpec(raw, name); err != nil {
+		return fmt.Errorf("failed to write CDI Spec %q: %w", name, err)
+	}
+
+	fmt.Printf("Generated CDI Spec %q with %d devices.\n", name, len(raw.Devices))
+
+	return nil
+}
+
+// parseMount parses a <host-path>[:<container-path>[:<option>,...]] mount.
+func parseMount(m string) (*specs.Mount, error) {
+	parts := strings.SplitN(m, ":", 3)
+	mount := &specs.Mount{
+		HostPath:      parts[0],
+		ContainerPath: parts[0],
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		mount.ContainerPath = parts[1]
+	}
+	if len(parts) > 2 && parts[2] != "" {
+		mount.Options = strings.Split(parts[2], ",")
+	}
+	if mount.HostPath == "" {
+		return nil, fmt.Errorf("invalid mount %q, empty host path", m)
+	}
+	return mount, nil
+}
+
+func cdiGC(dryRun bool, aliveCmd string) error {
+	var (
+		registry = cdi.GetRegistry()
+		alive    cdi.OwnerAliveFunc
+	)
+
+	if aliveCmd != "" {
+		args := strings.Fields(aliveCmd)
+		if len(args) == 0 {
+			return fmt.Errorf("invalid owner liveness command %q", aliveCmd)
+		}
+		alive = func(owner string) (bool, error) {
+			err := exec.Command(args[0], append(args[1:], owner)...).Run()
+			if err == nil {
+				return true, nil
+			}
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+				return false, nil
+			}
+			return false, err
+		}
+	}
+
+	removed, err := cdi.NewTransientSpecManager(registry.SpecDB()).GC(alive, dryRun)
+	for _, path := range removed {
+		if dryRun {
+			fmt.Printf("Stale CDI Spec %s\n", path)
+		} else {
+			fmt.Printf("Removed CDI Spec %s\n", path)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("CDI Spec garbage collection failed: %w", err)
+	}
+
+	return nil
+}
+
+func cdiConvertSpecs(target string, dryRun bool, paths ...string) bool {
+	ok := true
+	for _, path := range paths {
+		from, to, err := cdi.ConvertSpecFile(path, target, dryRun)
+
+		var convErr *cdi.ConversionError
+		switch {
+		case errors.As(err, &convErr):
+			fmt.Printf("%s: cannot convert to version %s, features requiring later versions:\n",
+				path, convErr.Version)
+			for _, f := range convErr.Features {
+				fmt.Printf("  - %s\n", f)
+			}
+			ok = false
+		case err != nil:
+			fmt.Printf("%s: %v\n", path, err)
+			ok = false
+		default:
+			fmt.Printf("%s: %s -> %s\n", path, from, to)
+		}
+	}
+
+	return ok
+}
+
+func cdiFormatSpecs(check, minimalVersion bool, paths ...string) bool {
+	ok := true
+	for _, path := range paths {
+		changed, err := cdi.FormatSpecFile(path, minimalVersion, check)
+		switch {
+		case err != nil:
+			fmt.Printf("%s: %v\n", path, err)
+			ok = false
+		case changed && check:
+			fmt.Printf("%s\n", path)
+			ok = false
+		case changed:
+			fmt.Printf("%s: formatted\n", path)
+		}
+	}
+
+	return ok
+}
+
+func cdiDiffSpecs(format, oldPath, newPath string) (bool, error) {
+	old, err := cdi.ReadSpec(oldPath, 0)
+	if err != nil {
+		return false, err
+	}
+	new, err := cdi.ReadSpec(newPath, 0)
+	if err != nil {
+		return false, err
+	}
+
+	d := diff.Specs(old.Spec, new.Spec)
+
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return false, fmt.Errorf("failed to encode CDI Spec diff: %w", err)
+		}
+		fmt.Printf("%s\n", data)
+	case "", "text":
+		fmt.Printf("%s", d)
+	default:
+		return false, fmt.Errorf("invalid output format %q", format)
+	}
+
+	return d.Empty(), nil
+}
+
+func cdiLint(flags *lintFlags, paths ...string) (bool, error) {
+	failOn, err := lint.ParseSeverity(flags.failOn)
+	if err != nil {
+		return false, err
+	}
+
+	var cdiSpecs []*cdi.Spec
+	if len(paths) == 0 {
+		registry := cdi.GetRegistry()
+		for _, vendor := range registry.SpecDB().ListVendors() {
+			cdiSpecs = append(cdiSpecs, registry.SpecDB().GetVendorSpecs(vendor)...)
+		}
+	} else {
+		for _, path := range paths {
+			spec, err := cdi.ReadSpec(path, 0)
+			if err != nil {
+				return false, err
+			}
+			cdiSpecs = append(cdiSpecs, spec)
+		}
+	}
+
+	findings := lint.New(
+		lint.WithDisabledRules(flags.disable...),
+		lint.WithHostRoot(flags.hostRoot),
+	).Lint(cdiSpecs...)
+
+	switch flags.output {
+	case "json":
+		if findings == nil {
+			findings = []*lint.Finding{}
+		}
+		data, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return false, fmt.Errorf("failed to encode lint findings: %w", err)
+		}
+		fmt.Printf("%s\n", data)
+	case "", "text":
+		for _, f := range findings {
+			fmt.Printf("%s\n", f)
+		}
+	default:
+		return false, fmt.Errorf("invalid output format %q", flags.output)
+	}
+
+	for _, f := range findings {
+		if f.Severity >= failOn {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,66 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"tags.cncf.io/container-device-interface/pkg/cdi"
+)
+
+type convertFlags struct {
+	to     string
+	dryRun bool
+}
+
+// convertCmd is our command for converting Spec files between versions.
+var convertCmd = &cobra.Command{
+	Use:   "convert --to <version> <Spec-file-list>",
+	Short: "Convert CDI Spec files to another version",
+	Long: `
+The 'convert' command rewrites the version of the given CDI Spec files
+in place, preserving their encoding. The target version is either an
+explicit version, 'lowest' for the lowest version compatible with the
+content of each Spec file, or 'latest' for the latest version. If a Spec
+file uses features not supported by the target version, these features
+are listed and the Spec file is left unchanged. Signed Spec files are
+not converted, since that would invalidate their signature. With --dry-run
+the files are only checked for conversion.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			fmt.Printf("CDI Spec file argument(s) expected\n")
+			os.Exit(1)
+		}
+		if !cdiConvertSpecs(convertCfg.to, convertCfg.dryRun, args...) {
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	convertCfg convertFlags
+)
+
+func init() {
+	specCmd.AddCommand(convertCmd)
+	convertCmd.Flags().StringVar(&convertCfg.to,
+		"to", cdi.LowestVersion, "target version (lowest|latest|<version>)")
+	convertCmd.Flags().BoolVar(&convertCfg.dryRun,
+		"dry-run", false, "only check if the Spec files can be converted")
+}
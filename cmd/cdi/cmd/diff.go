@@ -0,0 +1,65 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+type diffFlags struct {
+	output string
+}
+
+// diffCmd is our command for comparing Spec files.
+var diffCmd = &cobra.Command{
+	Use:   "diff <old-Spec-file> <new-Spec-file>",
+	Short: "Show the semantic differences between two CDI Spec files",
+	Long: `
+The 'diff' command shows the semantic differences between two CDI Spec
+files: devices added or removed, and container edits added, removed or
+modified. Devices are matched by name, so their order does not matter.
+The same goes for environment variables, device nodes, and mounts, except
+for mounts with the same container path. Hooks are compared in order. The
+command exits with an exit status of 1 if the Spec files differ.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 2 {
+			fmt.Printf("two CDI Spec file arguments expected\n")
+			os.Exit(1)
+		}
+		same, err := cdiDiffSpecs(diffCfg.output, args[0], args[1])
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(2)
+		}
+		if !same {
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	diffCfg diffFlags
+)
+
+func init() {
+	specCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringVarP(&diffCfg.output,
+		"output", "o", "", "output format (text|json)")
+}
@@ -0,0 +1,65 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+type fmtFlags struct {
+	check          bool
+	minimalVersion bool
+}
+
+// fmtCmd is our command for formatting Spec files.
+var fmtCmd = &cobra.Command{
+	Use:   "fmt <Spec-file-list>",
+	Short: "Rewrite CDI Spec files in canonical form",
+	Long: `
+The 'fmt' command rewrites the given CDI Spec files in place in canonical
+form, preserving their encoding. Devices are sorted by name, paths are
+cleaned, and annotations are sorted. With --minimal-version the version
+of each Spec file is lowered to the lowest version compatible with its
+content. Signed Spec files are only rewritten if their content stays the
+same, since otherwise their signature would be invalidated. With --check
+the files are not rewritten, but the ones not in canonical form are
+listed and the command exits with an exit status of 1 if there are any.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			fmt.Printf("CDI Spec file argument(s) expected\n")
+			os.Exit(1)
+		}
+		if !cdiFormatSpecs(fmtCfg.check, fmtCfg.minimalVersion, args...) {
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	fmtCfg fmtFlags
+)
+
+func init() {
+	rootCmd.AddCommand(fmtCmd)
+	fmtCmd.Flags().BoolVar(&fmtCfg.check,
+		"check", false, "only list Spec files not in canonical form")
+	fmtCmd.Flags().BoolVar(&fmtCfg.minimalVersion,
+		"minimal-version", false, "lower the version to the minimum required")
+}
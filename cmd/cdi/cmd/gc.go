@@ -0,0 +1,62 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+type gcFlags struct {
+	dryRun   bool
+	aliveCmd string
+}
+
+// gcCmd is our command for garbage collecting transient Specs.
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove stale transient CDI Specs",
+	Long: `
+The 'gc' command removes stale transient CDI Spec files. Transient Specs
+are stale once their time to live expires. If --alive-cmd is given, they
+are also stale once their owner is gone. The command is then run with the
+owner ID as its last argument, and it should exit with an exit status of
+0 if the owner is alive, 1 if it is gone, or anything else on failure.
+Only Spec files in the highest priority Spec directory, where transient
+Specs are written to, are considered. Spec files in other directories or
+without transient owner annotations are never removed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cdiGC(gcCfg.dryRun, gcCfg.aliveCmd); err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	gcCfg gcFlags
+)
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+	gcCmd.Flags().BoolVar(&gcCfg.dryRun,
+		"dry-run", false, "only list stale Specs, do not remove them")
+	gcCmd.Flags().StringVar(&gcCfg.aliveCmd,
+		"alive-cmd", "", "command to check if the owner of a transient Spec is alive")
+}
@@ -0,0 +1,79 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+type generateFlags struct {
+	name     string
+	env      []string
+	mounts   []string
+	hostRoot string
+	dryRun   bool
+	output   string
+}
+
+// generateCmd is our command for generating Specs from host device nodes.
+var generateCmd = &cobra.Command{
+	Aliases: []string{"gen"},
+	Use:     "generate <vendor/class> <device-node-glob-list>",
+	Short:   "Generate a CDI Spec from host device nodes",
+	Long: `
+The 'generate' command generates a CDI Spec for simple devices. Every
+host device node matching any of the given glob patterns, for instance
+'/dev/kvm' or '/dev/hidraw*', becomes a device named after the base name
+of the device node. The environment variables and mounts given are added
+to every device. The Spec is written to the highest priority CDI Spec
+directory, unless --dry-run is given.
+
+Mounts are given as <host-path>[:<container-path>[:<option>,...]].`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			fmt.Printf("vendor/class and device node glob(s) expected\n")
+			os.Exit(1)
+		}
+		if err := cdiGenerateSpec(&generateCfg, args[0], args[1:]...); err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	generateCfg generateFlags
+)
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+	generateCmd.Flags().StringVarP(&generateCfg.name,
+		"name", "n", "", "name of the Spec file, defaults to <vendor>-<class>")
+	generateCmd.Flags().StringArrayVarP(&generateCfg.env,
+		"env", "e", nil, "environment variable (NAME=VALUE) to add to each device")
+	generateCmd.Flags().StringArrayVarP(&generateCfg.mounts,
+		"mount", "m", nil, "mount to add to each device")
+	generateCmd.Flags().StringVar(&generateCfg.hostRoot,
+		"host-root", "", "directory the host filesystem is available under")
+	generateCmd.Flags().BoolVar(&generateCfg.dryRun,
+		"dry-run", false, "print the generated Spec instead of writing it")
+	generateCmd.Flags().StringVarP(&generateCfg.output,
+		"output", "o", "", "output format for --dry-run (json|yaml)")
+}
@@ -28,7 +28,9 @@ import (
 )
 
 type injectFlags struct {
-	output string
+	output   string
+	rootless bool
+	hostRoot string
 }
 
 // injectCmd is our command for injecting CDI devices into an OCI Spec.
@@ -39,7 +41,11 @@ var injectCmd = &cobra.Command{
 	Long: `
 The 'inject' command reads an OCI Spec from a file (use "-" for stdin),
 injects a requested set of CDI devices into it and dumps the resulting
-updated OCI Spec.`,
+updated OCI Spec. Devices can be requested by qualified name or using a
+glob pattern for the device name, for instance 'vendor.com/class=gpu*'.
+The reserved device name 'all' requests all devices of a vendor/class.
+Glob patterns matching the full qualified name, for instance '*' or
+'vendor.com/*', are matched against all devices in the registry.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 2 {
 			fmt.Printf("OCI Spec argument and devices expected\n")
@@ -51,7 +57,7 @@ updated OCI Spec.`,
 			fmt.Printf("%v\n", err)
 			os.Exit(1)
 		}
-		if err := cdiInjectDevices(injectCfg.output, ociSpec, args[1:]); err != nil {
+		if err := cdiInjectDevices(injectCfg.output, injectCfg.rootless, injectCfg.hostRoot, ociSpec, args[1:]); err != nil {
 			fmt.Printf("%v\n", err)
 			os.Exit(1)
 		}
@@ -91,4 +97,8 @@ func init() {
 	rootCmd.AddCommand(injectCmd)
 	injectCmd.Flags().StringVarP(&injectCfg.output,
 		"output", "o", "", "output format for OCI Spec (json|yaml)")
+	injectCmd.Flags().BoolVar(&injectCfg.rootless,
+		"rootless", false, "inject device nodes as bind mounts for rootless containers")
+	injectCmd.Flags().StringVar(&injectCfg.hostRoot,
+		"host-root", "", "directory the host filesystem is available under")
 }
@@ -0,0 +1,71 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+type lintFlags struct {
+	output   string
+	disable  []string
+	hostRoot string
+	failOn   string
+}
+
+// lintCmd is our command for linting CDI Spec files.
+var lintCmd = &cobra.Command{
+	Use:   "lint [Spec files]",
+	Short: "Check CDI Spec files for likely mistakes",
+	Long: `
+The 'lint' command checks CDI Spec files for likely mistakes, which are
+not caught by validation, such as host paths which do not exist or hooks
+which are not executable. Without arguments, the Spec files in the CDI
+registry are checked. Otherwise the given Spec files are. Every finding
+is reported with the ID of the rule reporting it, which can be used to
+disable the rule. The command exits with an exit status of 1 if any
+finding of at least the --fail-on severity is reported.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ok, err := cdiLint(&lintCfg, args...)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(2)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	lintCfg lintFlags
+)
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().StringVarP(&lintCfg.output,
+		"output", "o", "", "output format (text|json)")
+	lintCmd.Flags().StringSliceVar(&lintCfg.disable,
+		"disable", nil, "IDs of rules to disable")
+	lintCmd.Flags().StringVar(&lintCfg.hostRoot,
+		"host-root", "", "directory the host filesystem is available under")
+	lintCmd.Flags().StringVar(&lintCfg.failOn,
+		"fail-on", "warning", "lowest severity to fail on (info|warning|error)")
+}
@@ -0,0 +1,44 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// metricsCmd is our command for emitting CDI registry metrics.
+var metricsCmd = &cobra.Command{
+	Use:   "metrics [<CDI-device-list>]",
+	Short: "Emit CDI registry metrics",
+	Long: `
+The 'metrics' command refreshes the CDI registry and emits the collected
+metrics in the Prometheus text format. If any devices are given, those
+are injected into an empty OCI Spec to collect injection metrics, too.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cdiPrintMetrics(args...); err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(metricsCmd)
+}
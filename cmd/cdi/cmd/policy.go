@@ -0,0 +1,76 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+type policyCheckFlags struct {
+	policy string
+}
+
+// policyCmd is our parent command for CDI admission policies.
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Work with CDI admission policies",
+	Long: `
+The 'policy' command groups subcommands for working with declarative
+CDI admission policies, which restrict the edits CDI devices can make
+to containers.`,
+}
+
+// policyCheckCmd is our command for checking Spec files against a policy.
+var policyCheckCmd = &cobra.Command{
+	Use:   "check --policy <policy-file> <Spec-file-list>",
+	Short: "Check CDI Spec files against an admission policy",
+	Long: `
+The 'check' command checks every device in the given CDI Spec files
+against an admission policy, as if the device alone was injected into
+a container. Then it checks the number of devices, as if all of them
+were injected into a single container. It lists all policy violations
+and exits with an exit status of 1 if any violations are found.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			fmt.Printf("CDI Spec file argument(s) expected\n")
+			os.Exit(1)
+		}
+		ok, err := cdiCheckPolicy(policyCheckCfg.policy, args...)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	policyCheckCfg policyCheckFlags
+)
+
+func init() {
+	rootCmd.AddCommand(policyCmd)
+	policyCmd.AddCommand(policyCheckCmd)
+	policyCheckCmd.Flags().StringVarP(&policyCheckCfg.policy,
+		"policy", "p", "", "admission policy file to check against")
+	policyCheckCmd.MarkFlagRequired("policy")
+}
@@ -56,7 +56,8 @@ func Execute() {
 func init() {
 	cobra.OnInitialize(initSpecDirs)
 	rootCmd.PersistentFlags().StringSliceVarP(&specDirs, "spec-dirs", "d", nil, "directories to scan for CDI Spec files")
-	rootCmd.PersistentFlags().StringVarP(&schemaName, "schema", "s", "builtin", "JSON schema to use for validation")
+	rootCmd.PersistentFlags().StringVarP(&schemaName, "schema", "s", "builtin",
+		"JSON schema to use for validation (builtin|builtin:<version>|none|<path>)")
 }
 
 func initSpecDirs() {
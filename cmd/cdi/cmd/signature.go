@@ -0,0 +1,92 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+type signFlags struct {
+	key string
+}
+
+type verifyFlags struct {
+	keyDir string
+}
+
+// signCmd is our command for signing Spec files.
+var signCmd = &cobra.Command{
+	Use:   "sign --key <private-key> <Spec-file-list>",
+	Short: "Sign CDI Spec files",
+	Long: `
+The 'sign' command creates a detached signature for each given CDI
+Spec file. The signature is written next to the Spec file, to a file
+with the name of the Spec file and a '.sig' extension appended. The
+private key must be a PEM-encoded PKCS #8 ed25519 key, as generated
+for instance by 'openssl genpkey -algorithm ed25519'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			fmt.Printf("CDI Spec file argument(s) expected\n")
+			os.Exit(1)
+		}
+		if err := cdiSignSpecs(signCfg.key, args...); err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// verifyCmd is our command for verifying Spec file signatures.
+var verifyCmd = &cobra.Command{
+	Use:   "verify --key-dir <public-key-dir> <Spec-file-list>",
+	Short: "Verify CDI Spec file signatures",
+	Long: `
+The 'verify' command verifies the detached signature of each given
+CDI Spec file against the trusted public keys in a key directory. The
+public keys must be PEM-encoded PKIX ed25519 keys in files with a
+'.pem' extension. It exits with an exit status of 1 if verification
+fails for any of the files.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			fmt.Printf("CDI Spec file argument(s) expected\n")
+			os.Exit(1)
+		}
+		if !cdiVerifySpecs(verifyCfg.keyDir, args...) {
+			os.Exit(1)
+		}
+	},
+}
+
+var (
+	signCfg   signFlags
+	verifyCfg verifyFlags
+)
+
+func init() {
+	specCmd.AddCommand(signCmd)
+	signCmd.Flags().StringVarP(&signCfg.key,
+		"key", "k", "", "private key to sign with")
+	signCmd.MarkFlagRequired("key")
+
+	specCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().StringVarP(&verifyCfg.keyDir,
+		"key-dir", "k", "", "directory with trusted public keys")
+	verifyCmd.MarkFlagRequired("key-dir")
+}
@@ -0,0 +1,34 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// specCmd is our parent command for operating on individual Spec files.
+var specCmd = &cobra.Command{
+	Use:   "spec",
+	Short: "Operate on CDI Spec files",
+	Long: `
+The 'spec' command groups subcommands which operate on individual
+CDI Spec files instead of the content of the registry.`,
+}
+
+func init() {
+	rootCmd.AddCommand(specCmd)
+}
@@ -33,7 +33,12 @@ var validateCmd = &cobra.Command{
 	Long: `
 The 'validate' command lists errors encountered during the population
 of the CDI registry. It exits with an exit status of 1 if any errors
-were reported by the registry.`,
+were reported by the registry.
+
+Spec files are validated against the JSON schema selected by --schema.
+The 'builtin' schema validates each Spec file against the schema of its
+cdiVersion. Use 'builtin:<version>', for instance 'builtin:v0.5.0', to
+validate all Spec files against the schema of the given version.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cdiErrors := cdi.GetRegistry().GetErrors()
 		if len(cdiErrors) == 0 {
@@ -9,6 +9,7 @@ require (
 	github.com/spf13/cobra v1.6.0
 	sigs.k8s.io/yaml v1.3.0
 	tags.cncf.io/container-device-interface v0.0.0
+	tags.cncf.io/container-device-interface/specs-go v0.6.0
 )
 
 require (
@@ -22,7 +23,6 @@ require (
 	golang.org/x/mod v0.4.2 // indirect
 	golang.org/x/sys v0.1.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	tags.cncf.io/container-device-interface/specs-go v0.6.0 // indirect
 )
 
 replace tags.cncf.io/container-device-interface => ../..
@@ -57,7 +57,7 @@ func main() {
 		flag.PrintDefaults()
 	}
 
-	flag.StringVar(&schemaFile, "schema", "builtin", "JSON Schema to validate against")
+	flag.StringVar(&schemaFile, "schema", "builtin", "JSON Schema to validate against (builtin|builtin:<version>|none|<path>)")
 	flag.Parse()
 
 	if schemaFile != "" {
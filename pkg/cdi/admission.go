@@ -0,0 +1,71 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"strings"
+)
+
+// AdmissionPolicy decides whether devices can be injected into a
+// container. It is evaluated against the merged container edits of
+// the devices before these are applied to the OCI Spec. A declarative
+// implementation is available in the policy package.
+type AdmissionPolicy interface {
+	// Check returns any violations of the policy by injecting the given
+	// devices with the given merged container edits.
+	Check(devices []string, edits *ContainerEdits) []*PolicyViolation
+}
+
+// PolicyViolation describes a single violation of an AdmissionPolicy.
+type PolicyViolation struct {
+	// Rule is the name of the violated rule.
+	Rule string `json:"rule"`
+	// Subject is the offending entity, for instance a mount host path.
+	Subject string `json:"subject,omitempty"`
+	// Message is a human-readable description of the violation.
+	Message string `json:"message"`
+}
+
+// String returns a human-readable description of the violation.
+func (v *PolicyViolation) String() string {
+	return v.Rule + ": " + v.Message
+}
+
+// PolicyError is the error returned when injection is denied by the
+// AdmissionPolicy of the Cache.
+type PolicyError struct {
+	Violations []*PolicyViolation
+}
+
+// Error returns the error message for the policy violations.
+func (e *PolicyError) Error() string {
+	var msgs []string
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.String())
+	}
+	return "CDI injection denied by policy: " + strings.Join(msgs, "; ")
+}
+
+// WithAdmissionPolicy returns an option to set the AdmissionPolicy for
+// device injection. Injection fails with a *PolicyError if the policy
+// is violated. By default, no policy is enforced.
+func WithAdmissionPolicy(policy AdmissionPolicy) Option {
+	return func(c *Cache) error {
+		c.admissionPolicy = policy
+		return nil
+	}
+}
@@ -0,0 +1,113 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	oci "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+// denyEnvPolicy denies the injection of devices which set the given
+// environment variable.
+type denyEnvPolicy struct {
+	env     string
+	devices []string
+	edits   *ContainerEdits
+}
+
+func (p *denyEnvPolicy) Check(devices []string, edits *ContainerEdits) []*PolicyViolation {
+	p.devices, p.edits = devices, edits
+	for _, env := range edits.Env {
+		if env == p.env {
+			return []*PolicyViolation{
+				{
+					Rule:    "denyEnv",
+					Subject: env,
+					Message: "environment variable " + env + " denied",
+				},
+			}
+		}
+	}
+	return nil
+}
+
+func TestAdmissionPolicy(t *testing.T) {
+	const (
+		vendor1 = `
+cdiVersion: "0.3.0"
+kind:       "vendor1.com/device"
+containerEdits:
+  env:
+  - "VENDOR1_SPEC=VAL"
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV1=VAL1"
+  - name: "dev2"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV2=VAL2"
+`
+	)
+
+	dir, err := createSpecDirs(t, map[string]string{"vendor1.yaml": vendor1}, nil)
+	require.NoError(t, err)
+
+	policy := &denyEnvPolicy{env: "VENDOR1_DEV2=VAL2"}
+	cache, err := NewCache(
+		WithSpecDirs(
+			filepath.Join(dir, "etc"),
+			filepath.Join(dir, "run"),
+		),
+		WithAutoRefresh(false),
+		WithAdmissionPolicy(policy),
+	)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	ociSpec := &oci.Spec{}
+	report, err := cache.InjectDevicesWithReport(ociSpec, "vendor1.com/device=dev1")
+	require.NoError(t, err)
+	require.Empty(t, report.Violations)
+	require.Equal(t, []string{"vendor1.com/device=dev1"}, policy.devices)
+	require.Equal(t, []string{"VENDOR1_SPEC=VAL", "VENDOR1_DEV1=VAL1"}, policy.edits.Env)
+	require.Equal(t, []string{"VENDOR1_SPEC=VAL", "VENDOR1_DEV1=VAL1"}, ociSpec.Process.Env)
+
+	ociSpec = &oci.Spec{}
+	report, err = cache.InjectDevicesWithReport(ociSpec, "vendor1.com/device=dev1", "vendor1.com/device=dev2")
+	require.Error(t, err)
+
+	var policyErr *PolicyError
+	require.True(t, errors.As(err, &policyErr))
+	require.Equal(t, report.Violations, policyErr.Violations)
+	require.Equal(t,
+		[]*PolicyViolation{
+			{
+				Rule:    "denyEnv",
+				Subject: "VENDOR1_DEV2=VAL2",
+				Message: "environment variable VENDOR1_DEV2=VAL2 denied",
+			},
+		},
+		report.Violations,
+	)
+	require.Equal(t, &oci.Spec{}, ociSpec)
+}
@@ -0,0 +1,293 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package builder provides a fluent API for producing CDI Specs. Every
+// addition to a Spec is validated right away. Errors are collected and
+// reported by Build, each prefixed with the path of the offending field:
+//
+//	b := builder.NewSpec("vendor.com", "gpu")
+//	b.AddEnv("VENDOR_GPU_DRIVER=/usr/lib/vendor")
+//	b.Device("gpu0").
+//		AddDeviceNodeFromHost("/dev/vendor-gpu0", "").
+//		AddBindMount("/usr/lib/vendor", "/usr/lib/vendor", "ro")
+//	spec, err := b.Build()
+//
+// The Kind of the Spec is set from the vendor and class, and its version
+// is set to the minimum version required for the content of the Spec.
+package builder
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/semver"
+
+	"tags.cncf.io/container-device-interface/internal/multierror"
+	"tags.cncf.io/container-device-interface/internal/validation"
+	"tags.cncf.io/container-device-interface/pkg/cdi"
+	"tags.cncf.io/container-device-interface/specs-go"
+)
+
+// SpecBuilder builds a CDI Spec.
+type SpecBuilder struct {
+	spec    *specs.Spec
+	version string
+	devices []*DeviceBuilder
+	edits   editsBuilder
+	errors  []error
+}
+
+// DeviceBuilder builds a device of a CDI Spec.
+type DeviceBuilder struct {
+	spec   *SpecBuilder
+	device *specs.Device
+	path   string
+	edits  editsBuilder
+}
+
+// NewSpec creates a builder for a Spec with devices of the given vendor
+// and class.
+func NewSpec(vendor, class string) *SpecBuilder {
+	b := &SpecBuilder{
+		spec: &specs.Spec{
+			Kind: vendor + "/" + class,
+		},
+	}
+	b.edits = editsBuilder{
+		edits:  &b.spec.ContainerEdits,
+		path:   "containerEdits",
+		errorf: b.errorf,
+	}
+
+	if err := cdi.ValidateVendorName(vendor); err != nil {
+		b.errorf("kind: %w", err)
+	}
+	if err := cdi.ValidateClassName(class); err != nil {
+		b.errorf("kind: %w", err)
+	}
+
+	return b
+}
+
+// WithVersion sets the version of the Spec. By default, the minimum
+// version required for the content of the Spec is used. Build fails if
+// the given version is not a valid Spec version, or if it is lower than
+// the required one.
+func (b *SpecBuilder) WithVersion(version string) *SpecBuilder {
+	b.version = ""
+	v := strings.TrimPrefix(version, "v")
+	for _, valid := range cdi.SpecVersions() {
+		if v == valid {
+			b.version = v
+			return b
+		}
+	}
+	b.errorf("cdiVersion: invalid version %q", version)
+	return b
+}
+
+// AddAnnotation adds an annotation to the Spec.
+func (b *SpecBuilder) AddAnnotation(key, value string) *SpecBuilder {
+	if b.spec.Annotations == nil {
+		b.spec.Annotations = map[string]string{}
+	}
+	b.spec.Annotations[key] = value
+	if err := validation.ValidateSpecAnnotations(b.spec.Kind, map[string]string{key: value}); err != nil {
+		b.errorf("annotations[%q]: %w", key, err)
+	}
+	return b
+}
+
+// AddEnv adds environment variables to the Spec-level container edits.
+func (b *SpecBuilder) AddEnv(env ...string) *SpecBuilder {
+	b.edits.addEnv(env...)
+	return b
+}
+
+// AddDeviceNode adds a device node to the Spec-level container edits.
+func (b *SpecBuilder) AddDeviceNode(node *specs.DeviceNode) *SpecBuilder {
+	b.edits.addDeviceNode(node)
+	return b
+}
+
+// AddDeviceNodeFromHost adds a device node for the given host device to
+// the Spec-level container edits. See DeviceBuilder.AddDeviceNodeFromHost.
+func (b *SpecBuilder) AddDeviceNodeFromHost(hostPath, containerPath string) *SpecBuilder {
+	b.edits.addDeviceNodeFromHost(hostPath, containerPath)
+	return b
+}
+
+// AddMount adds a mount to the Spec-level container edits.
+func (b *SpecBuilder) AddMount(mount *specs.Mount) *SpecBuilder {
+	b.edits.addMount(mount)
+	return b
+}
+
+// AddBindMount adds a bind mount to the Spec-level container edits.
+// See DeviceBuilder.AddBindMount.
+func (b *SpecBuilder) AddBindMount(hostPath, containerPath string, options ...string) *SpecBuilder {
+	b.edits.addBindMount(hostPath, containerPath, options...)
+	return b
+}
+
+// AddHook adds a hook to the Spec-level container edits.
+func (b *SpecBuilder) AddHook(hook *specs.Hook) *SpecBuilder {
+	b.edits.addHook(hook)
+	return b
+}
+
+// Device adds a new device with the given name to the Spec and returns
+// a builder for it.
+func (b *SpecBuilder) Device(name string) *DeviceBuilder {
+	d := &DeviceBuilder{
+		spec:   b,
+		device: &specs.Device{Name: name},
+		path:   fmt.Sprintf("devices[%d]", len(b.devices)),
+	}
+	d.edits = editsBuilder{
+		edits:  &d.device.ContainerEdits,
+		path:   d.path + ".containerEdits",
+		errorf: b.errorf,
+	}
+
+	if err := cdi.ValidateDeviceName(name); err != nil {
+		b.errorf("%s.name: %w", d.path, err)
+	}
+	for i, other := range b.devices {
+		if other.device.Name == name {
+			b.errorf("%s.name: duplicate device %q, already defined by devices[%d]",
+				d.path, name, i)
+		}
+	}
+
+	b.devices = append(b.devices, d)
+	return d
+}
+
+// Err returns the errors encountered so far, if any.
+func (b *SpecBuilder) Err() error {
+	return multierror.New(append([]error{}, b.errors...)...)
+}
+
+// Build returns the built Spec. The version of the Spec is set to the
+// minimum version required by its content, unless a version was given
+// using WithVersion. An error is returned if any errors were encountered
+// while building the Spec.
+func (b *SpecBuilder) Build() (*specs.Spec, error) {
+	errs := append([]error{}, b.errors...)
+	errorf := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	spec := specs.Spec{
+		Kind:           b.spec.Kind,
+		Annotations:    b.spec.Annotations,
+		ContainerEdits: b.spec.ContainerEdits,
+	}
+	for _, d := range b.devices {
+		if d.edits.isEmpty() {
+			errorf("%s: device %q has no container edits", d.path, d.device.Name)
+		}
+		spec.Devices = append(spec.Devices, *d.device)
+	}
+	if len(spec.Devices) == 0 {
+		errorf("devices: no devices")
+	}
+
+	minVersion, err := cdi.MinimumRequiredVersion(&spec)
+	if err != nil {
+		errorf("cdiVersion: %w", err)
+	}
+	spec.Version = minVersion
+	if b.version != "" {
+		if semver.Compare("v"+b.version, "v"+minVersion) < 0 {
+			errorf("cdiVersion: version %q is lower than the required version %q",
+				b.version, minVersion)
+		}
+		spec.Version = b.version
+	}
+
+	if err := multierror.New(errs...); err != nil {
+		return nil, err
+	}
+
+	// the built Spec must not share any data with the builder
+	return cdi.CloneSpec(&spec), nil
+}
+
+// AddAnnotation adds an annotation to the device.
+func (d *DeviceBuilder) AddAnnotation(key, value string) *DeviceBuilder {
+	if d.device.Annotations == nil {
+		d.device.Annotations = map[string]string{}
+	}
+	d.device.Annotations[key] = value
+	name := d.spec.spec.Kind + "=" + d.device.Name
+	if err := validation.ValidateSpecAnnotations(name, map[string]string{key: value}); err != nil {
+		d.spec.errorf("%s.annotations[%q]: %w", d.path, key, err)
+	}
+	return d
+}
+
+// AddEnv adds environment variables to the device.
+func (d *DeviceBuilder) AddEnv(env ...string) *DeviceBuilder {
+	d.edits.addEnv(env...)
+	return d
+}
+
+// AddDeviceNode adds a device node to the device.
+func (d *DeviceBuilder) AddDeviceNode(node *specs.DeviceNode) *DeviceBuilder {
+	d.edits.addDeviceNode(node)
+	return d
+}
+
+// AddDeviceNodeFromHost adds a device node for the given host device to
+// the device. The type and the major and minor numbers of the device node
+// are looked up from the host device. If containerPath is empty, the device
+// node has the same path in the container as on the host.
+func (d *DeviceBuilder) AddDeviceNodeFromHost(hostPath, containerPath string) *DeviceBuilder {
+	d.edits.addDeviceNodeFromHost(hostPath, containerPath)
+	return d
+}
+
+// AddMount adds a mount to the device.
+func (d *DeviceBuilder) AddMount(mount *specs.Mount) *DeviceBuilder {
+	d.edits.addMount(mount)
+	return d
+}
+
+// AddBindMount adds a bind mount of the given host path to the device.
+// If containerPath is empty, the host path is used in the container. The
+// "bind" option is added unless a "bind" or "rbind" option is given.
+func (d *DeviceBuilder) AddBindMount(hostPath, containerPath string, options ...string) *DeviceBuilder {
+	d.edits.addBindMount(hostPath, containerPath, options...)
+	return d
+}
+
+// AddHook adds a hook to the device.
+func (d *DeviceBuilder) AddHook(hook *specs.Hook) *DeviceBuilder {
+	d.edits.addHook(hook)
+	return d
+}
+
+// Spec returns the builder of the Spec the device belongs to.
+func (d *DeviceBuilder) Spec() *SpecBuilder {
+	return d.spec
+}
+
+// errorf records an error.
+func (b *SpecBuilder) errorf(format string, args ...any) {
+	b.errors = append(b.errors, fmt.Errorf(format, args...))
+}
@@ -0,0 +1,271 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package builder
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"tags.cncf.io/container-device-interface/pkg/cdi"
+	"tags.cncf.io/container-device-interface/specs-go"
+)
+
+func TestSpecBuilder(t *testing.T) {
+	type testCase struct {
+		name    string
+		build   func() *SpecBuilder
+		version string
+		check   func(*testing.T, *specs.Spec)
+		errors  []string
+	}
+	for _, tc := range []*testCase{
+		{
+			name: "minimal spec",
+			build: func() *SpecBuilder {
+				b := NewSpec("vendor.com", "device")
+				b.Device("dev0").AddEnv("FOO=bar")
+				return b
+			},
+			version: "0.3.0",
+			check: func(t *testing.T, spec *specs.Spec) {
+				require.Equal(t, "vendor.com/device", spec.Kind)
+				require.Len(t, spec.Devices, 1)
+				require.Equal(t, "dev0", spec.Devices[0].Name)
+				require.Equal(t, []string{"FOO=bar"}, spec.Devices[0].ContainerEdits.Env)
+			},
+		},
+		{
+			name: "bind mount",
+			build: func() *SpecBuilder {
+				b := NewSpec("vendor.com", "device")
+				b.AddBindMount("/usr/lib/vendor", "", "ro")
+				b.Device("dev0").AddBindMount("/etc/vendor", "/etc/foo", "rbind")
+				return b
+			},
+			version: "0.3.0",
+			check: func(t *testing.T, spec *specs.Spec) {
+				require.Equal(t, []*specs.Mount{
+					{
+						HostPath:      "/usr/lib/vendor",
+						ContainerPath: "/usr/lib/vendor",
+						Options:       []string{"bind", "ro"},
+					},
+				}, spec.ContainerEdits.Mounts)
+				require.Equal(t, []*specs.Mount{
+					{
+						HostPath:      "/etc/vendor",
+						ContainerPath: "/etc/foo",
+						Options:       []string{"rbind"},
+					},
+				}, spec.Devices[0].ContainerEdits.Mounts)
+			},
+		},
+		{
+			name: "version from content",
+			build: func() *SpecBuilder {
+				b := NewSpec("vendor.com", "device")
+				b.Device("dev0").
+					AddAnnotation("vendor.com/model", "foo").
+					AddEnv("FOO=bar")
+				return b
+			},
+			version: "0.6.0",
+		},
+		{
+			name: "version from content, host path",
+			build: func() *SpecBuilder {
+				b := NewSpec("vendor.com", "device")
+				b.Device("dev0").AddDeviceNode(&specs.DeviceNode{
+					Path:     "/dev/foo",
+					HostPath: "/dev/bar",
+				})
+				return b
+			},
+			version: "0.5.0",
+		},
+		{
+			name: "explicit version",
+			build: func() *SpecBuilder {
+				b := NewSpec("vendor.com", "device").WithVersion("0.5.0")
+				b.Device("dev0").AddEnv("FOO=bar")
+				return b
+			},
+			version: "0.5.0",
+		},
+		{
+			name: "explicit version too low",
+			build: func() *SpecBuilder {
+				b := NewSpec("vendor.com", "device").WithVersion("0.5.0")
+				b.Device("dev0").AddAnnotation("vendor.com/model", "foo").AddEnv("FOO=bar")
+				return b
+			},
+			errors: []string{
+				`cdiVersion: version "0.5.0" is lower than the required version "0.6.0"`,
+			},
+		},
+		{
+			name: "invalid version",
+			build: func() *SpecBuilder {
+				b := NewSpec("vendor.com", "device").WithVersion("1.0")
+				b.Device("dev0").AddEnv("FOO=bar")
+				return b
+			},
+			errors: []string{
+				`cdiVersion: invalid version "1.0"`,
+			},
+		},
+		{
+			name: "invalid kind",
+			build: func() *SpecBuilder {
+				b := NewSpec("vendor.com", "_device")
+				b.Device("dev0").AddEnv("FOO=bar")
+				return b
+			},
+			errors: []string{
+				"kind: ",
+			},
+		},
+		{
+			name: "no devices",
+			build: func() *SpecBuilder {
+				return NewSpec("vendor.com", "device").AddEnv("FOO=bar")
+			},
+			errors: []string{
+				"devices: no devices",
+			},
+		},
+		{
+			name: "error paths",
+			build: func() *SpecBuilder {
+				b := NewSpec("vendor.com", "device")
+				b.AddEnv("FOO=bar", "=baz")
+				b.Device("dev0").AddEnv("FOO=bar")
+				b.Device("dev1").
+					AddDeviceNode(&specs.DeviceNode{Path: "/dev/foo"}).
+					AddDeviceNode(&specs.DeviceNode{Path: "/dev/bar", Type: "x"}).
+					AddMount(&specs.Mount{HostPath: "/foo"}).
+					AddHook(&specs.Hook{HookName: "badHook", Path: "/bin/hook"})
+				b.Device("dev0").AddEnv("FOO=bar")
+				b.Device("dev3")
+				return b
+			},
+			errors: []string{
+				`containerEdits.env[1]: invalid environment variable "=baz"`,
+				`devices[1].containerEdits.deviceNodes[1]: device "/dev/bar": invalid type "x"`,
+				`devices[1].containerEdits.mounts[0]: invalid mount, empty container path`,
+				`devices[1].containerEdits.hooks[0]: invalid hook name "badHook"`,
+				`devices[2].name: duplicate device "dev0", already defined by devices[0]`,
+				`devices[3]: device "dev3" has no container edits`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			spec, err := tc.build().Build()
+			if len(tc.errors) > 0 {
+				require.Error(t, err)
+				require.Nil(t, spec)
+				for _, msg := range tc.errors {
+					require.Contains(t, err.Error(), msg)
+				}
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.version, spec.Version)
+			if tc.check != nil {
+				tc.check(t, spec)
+			}
+
+			// the built Spec must be accepted as is by a Cache
+			cache, err := cdi.NewCache(
+				cdi.WithSpecDirs(t.TempDir()),
+				cdi.WithAutoRefresh(false),
+			)
+			require.NoError(t, err)
+			defer cache.Close()
+			require.NoError(t, cache.WriteSpec(spec, "vendor"))
+			require.NoError(t, cache.Refresh())
+		})
+	}
+}
+
+func TestSpecBuilderErr(t *testing.T) {
+	b := NewSpec("vendor.com", "device")
+	require.NoError(t, b.Err())
+
+	b.Device("dev0").AddEnv("FOO")
+	require.Error(t, b.Err())
+	require.Contains(t, b.Err().Error(), `devices[0].containerEdits.env[0]`)
+
+	_, err := b.Build()
+	require.Error(t, err)
+	_, err = b.Build()
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "\n", "errors reported more than once")
+}
+
+func TestSpecBuilderCopy(t *testing.T) {
+	node := &specs.DeviceNode{Path: "/dev/vendor0", Type: "c", Major: 10, Minor: 1}
+	b := NewSpec("vendor.com", "device").AddAnnotation("vendor.com/foo", "bar")
+	d := b.Device("dev0").AddDeviceNode(node).AddBindMount("/usr/lib/vendor", "", "ro")
+
+	spec, err := b.Build()
+	require.NoError(t, err)
+
+	// changes to the builder after Build must not affect the built Spec
+	node.Path = "/dev/modified"
+	b.AddAnnotation("vendor.com/foo", "modified")
+	d.AddEnv("FOO=bar").AddAnnotation("vendor.com/foo", "bar")
+	d.device.ContainerEdits.Mounts[0].Options[0] = "modified"
+
+	require.Equal(t, map[string]string{"vendor.com/foo": "bar"}, spec.Annotations)
+	require.Nil(t, spec.Devices[0].Annotations)
+	require.Nil(t, spec.Devices[0].ContainerEdits.Env)
+	require.Equal(t, "/dev/vendor0", spec.Devices[0].ContainerEdits.DeviceNodes[0].Path)
+	require.Equal(t, []string{"bind", "ro"}, spec.Devices[0].ContainerEdits.Mounts[0].Options)
+}
+
+func TestAddDeviceNodeFromHost(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no host devices on windows")
+	}
+
+	b := NewSpec("vendor.com", "device")
+	b.Device("null").AddDeviceNodeFromHost("/dev/null", "")
+	b.Device("zero").AddDeviceNodeFromHost("/dev/zero", "/dev/foo")
+	spec, err := b.Build()
+	require.NoError(t, err)
+
+	null := spec.Devices[0].ContainerEdits.DeviceNodes[0]
+	require.Equal(t, "/dev/null", null.Path)
+	require.Equal(t, "", null.HostPath)
+	require.Equal(t, "c", null.Type)
+	require.Equal(t, int64(1), null.Major)
+	require.Equal(t, int64(3), null.Minor)
+
+	zero := spec.Devices[1].ContainerEdits.DeviceNodes[0]
+	require.Equal(t, "/dev/foo", zero.Path)
+	require.Equal(t, "/dev/zero", zero.HostPath)
+	require.Equal(t, "c", zero.Type)
+	require.Equal(t, "0.5.0", spec.Version)
+
+	b = NewSpec("vendor.com", "device")
+	b.Device("missing").AddDeviceNodeFromHost("/dev/no-such-device", "")
+	_, err = b.Build()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "devices[0].containerEdits.deviceNodes[0]: ")
+}
@@ -0,0 +1,116 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package builder
+
+import (
+	"tags.cncf.io/container-device-interface/pkg/cdi"
+	"tags.cncf.io/container-device-interface/specs-go"
+)
+
+// editsBuilder adds and validates container edits of a Spec or a device.
+type editsBuilder struct {
+	edits  *specs.ContainerEdits
+	path   string
+	errorf func(string, ...any)
+}
+
+func (e *editsBuilder) addEnv(env ...string) {
+	for _, v := range env {
+		if err := cdi.ValidateEnv([]string{v}); err != nil {
+			e.errorf("%s.env[%d]: %w", e.path, len(e.edits.Env), err)
+		}
+		e.edits.Env = append(e.edits.Env, v)
+	}
+}
+
+func (e *editsBuilder) addDeviceNode(node *specs.DeviceNode) {
+	if node == nil {
+		e.errorf("%s.deviceNodes[%d]: nil device node", e.path, len(e.edits.DeviceNodes))
+		return
+	}
+	if err := (&cdi.DeviceNode{DeviceNode: node}).Validate(); err != nil {
+		e.errorf("%s.deviceNodes[%d]: %w", e.path, len(e.edits.DeviceNodes), err)
+	}
+	e.edits.DeviceNodes = append(e.edits.DeviceNodes, node)
+}
+
+func (e *editsBuilder) addDeviceNodeFromHost(hostPath, containerPath string) {
+	if containerPath == "" {
+		containerPath = hostPath
+	}
+	node := &specs.DeviceNode{
+		Path:     containerPath,
+		HostPath: hostPath,
+	}
+	edits := &cdi.ContainerEdits{
+		ContainerEdits: &specs.ContainerEdits{
+			DeviceNodes: []*specs.DeviceNode{node},
+		},
+	}
+	if err := edits.FillMissingInfo(); err != nil {
+		e.errorf("%s.deviceNodes[%d]: %w", e.path, len(e.edits.DeviceNodes), err)
+	}
+	// Only set the host path if it differs, which requires a later version.
+	if node.HostPath == node.Path {
+		node.HostPath = ""
+	}
+	e.addDeviceNode(node)
+}
+
+func (e *editsBuilder) addMount(mount *specs.Mount) {
+	if mount == nil {
+		e.errorf("%s.mounts[%d]: nil mount", e.path, len(e.edits.Mounts))
+		return
+	}
+	if err := (&cdi.Mount{Mount: mount}).Validate(); err != nil {
+		e.errorf("%s.mounts[%d]: %w", e.path, len(e.edits.Mounts), err)
+	}
+	e.edits.Mounts = append(e.edits.Mounts, mount)
+}
+
+func (e *editsBuilder) addBindMount(hostPath, containerPath string, options ...string) {
+	if containerPath == "" {
+		containerPath = hostPath
+	}
+	opts := append([]string{"bind"}, options...)
+	for _, o := range options {
+		if o == "bind" || o == "rbind" {
+			opts = options
+			break
+		}
+	}
+	e.addMount(&specs.Mount{
+		HostPath:      hostPath,
+		ContainerPath: containerPath,
+		Options:       opts,
+	})
+}
+
+func (e *editsBuilder) addHook(hook *specs.Hook) {
+	if hook == nil {
+		e.errorf("%s.hooks[%d]: nil hook", e.path, len(e.edits.Hooks))
+		return
+	}
+	if err := (&cdi.Hook{Hook: hook}).Validate(); err != nil {
+		e.errorf("%s.hooks[%d]: %w", e.path, len(e.edits.Hooks), err)
+	}
+	e.edits.Hooks = append(e.edits.Hooks, hook)
+}
+
+func (e *editsBuilder) isEmpty() bool {
+	return len(e.edits.Env)+len(e.edits.DeviceNodes)+len(e.edits.Hooks)+len(e.edits.Mounts) == 0
+}
@@ -17,6 +17,8 @@
 package cdi
 
 import (
+	"context"
+	"crypto/ed25519"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -25,6 +27,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	oci "github.com/opencontainers/runtime-spec/specs-go"
@@ -35,6 +38,11 @@ import (
 // Option is an option to change some aspect of default CDI behavior.
 type Option func(*Cache) error
 
+var (
+	// ErrCacheClosed is returned when trying to use a closed Cache.
+	ErrCacheClosed = errors.New("CDI Cache is closed")
+)
+
 // Cache stores CDI Specs loaded from Spec directories.
 type Cache struct {
 	sync.Mutex
@@ -44,8 +52,21 @@ type Cache struct {
 	errors    map[string][]error
 	dirErrors map[string]error
 
-	autoRefresh bool
-	watch       *watch
+	autoRefresh      bool
+	recordProvenance bool
+	conflictPolicy   ConflictPolicy
+	logger           Logger
+	metrics          Metrics
+	validator        func(*cdi.Spec) error
+	trustPolicy      *SpecTrustPolicy
+	signatureKeyDir  string
+	maxVersion       string
+	admissionPolicy  AdmissionPolicy
+	rootless         bool
+	host             hostDevices
+	watch            *watch
+	refreshPending   bool
+	closed           bool
 }
 
 // WithAutoRefresh returns an option to control automatic Cache refresh.
@@ -65,15 +86,20 @@ func WithAutoRefresh(autoRefresh bool) Option {
 // option. The default set of directories is exposed in DefaultSpecDirs.
 func NewCache(options ...Option) (*Cache, error) {
 	c := &Cache{
-		autoRefresh: true,
-		watch:       &watch{},
+		autoRefresh:    true,
+		conflictPolicy: ConflictIgnore,
+		logger:         nopLogger{},
+		metrics:        nopMetrics{},
+		validator:      validateSpec,
+		watch:          &watch{},
 	}
 
 	WithSpecDirs(DefaultSpecDirs...)(c)
 	c.Lock()
 	defer c.Unlock()
 
-	return c, c.configure(options...)
+	_, err := c.configure(options...)
+	return c, err
 }
 
 // Configure applies options to the Cache. Updates and refreshes the
@@ -84,43 +110,88 @@ func (c *Cache) Configure(options ...Option) error {
 	}
 
 	c.Lock()
-	defer c.Unlock()
+	if c.closed {
+		c.Unlock()
+		return ErrCacheClosed
+	}
+	stopped, err := c.configure(options...)
+	c.Unlock()
+
+	// wait for any previous watcher, which might need the lock to exit
+	<-stopped
 
-	return c.configure(options...)
+	return err
+}
+
+// Close closes the Cache. It stops monitoring Spec directories for
+// changes and waits for the monitoring goroutine to exit. Once closed,
+// all Cache functions which return an error fail with ErrCacheClosed.
+// Closing an already closed Cache is a no-op.
+func (c *Cache) Close() error {
+	c.Lock()
+	if c.closed {
+		c.Unlock()
+		return nil
+	}
+	c.closed = true
+	stopped := c.watch.stop()
+	c.Unlock()
+
+	<-stopped
+	c.logger.Debug("closed CDI Cache")
+
+	return nil
 }
 
 // Configure the Cache. Start/stop CDI Spec directory watch, refresh
-// the Cache if necessary.
-func (c *Cache) configure(options ...Option) error {
+// the Cache if necessary. Returns a channel which is closed once any
+// previously started watch has stopped.
+func (c *Cache) configure(options ...Option) (<-chan struct{}, error) {
 	var err error
 
 	for _, o := range options {
 		if err = o(c); err != nil {
-			return fmt.Errorf("failed to apply cache options: %w", err)
+			return stoppedWatch, fmt.Errorf("failed to apply cache options: %w", err)
 		}
 	}
 
 	c.dirErrors = make(map[string]error)
 
-	c.watch.stop()
+	stopped := c.watch.stop()
+	c.watch.logger = c.logger
 	if c.autoRefresh {
 		c.watch.setup(c.specDirs, c.dirErrors)
-		c.watch.start(&c.Mutex, c.refresh, c.dirErrors)
+		c.watch.start(&c.Mutex, func() error { return c.refresh(context.Background()) }, c.dirErrors, c.logger)
 	}
-	c.refresh()
+	c.refresh(context.Background())
 
-	return nil
+	return stopped, nil
 }
 
 // Refresh rescans the CDI Spec directories and refreshes the Cache.
 // In manual refresh mode the cache is always refreshed. In auto-
 // refresh mode the cache is only refreshed if it is out of date.
 func (c *Cache) Refresh() error {
+	return c.RefreshContext(context.Background())
+}
+
+// RefreshContext is like Refresh but takes a context. If the context
+// gets canceled or expires during a refresh, the refresh is aborted,
+// the Cache is left as it was and the context error is returned.
+func (c *Cache) RefreshContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	c.Lock()
 	defer c.Unlock()
 
+	if c.closed {
+		return ErrCacheClosed
+	}
+
 	// force a refresh in manual mode
-	if refreshed, err := c.refreshIfRequired(!c.autoRefresh); refreshed {
+	if refreshed, err := c.refreshIfRequired(ctx, !c.autoRefresh); refreshed {
 		return err
 	}
 
@@ -133,15 +204,33 @@ func (c *Cache) Refresh() error {
 }
 
 // Refresh the Cache by rescanning CDI Spec directories and files.
-func (c *Cache) refresh() error {
+func (c *Cache) refresh(ctx context.Context) error {
 	var (
 		specs      = map[string][]*Spec{}
 		devices    = map[string]*Device{}
 		conflicts  = map[string]struct{}{}
 		specErrors = map[string][]error{}
 		result     []error
+		start      = time.Now()
+		dirStats   = make([]*SpecDirStats, len(c.specDirs))
 	)
 
+	for i, dir := range c.specDirs {
+		dirStats[i] = &SpecDirStats{Dir: dir}
+	}
+
+	var (
+		keys    []ed25519.PublicKey
+		keysErr error
+	)
+	if c.signatureKeyDir != "" {
+		keys, keysErr = ReadSignatureKeys(c.signatureKeyDir)
+		if keysErr != nil {
+			c.logger.Error("failed to read CDI Spec signature keys", "error", keysErr)
+			result = append(result, keysErr)
+		}
+	}
+
 	// collect errors per spec file path and once globally
 	collectError := func(err error, paths ...string) {
 		result = append(result, err)
@@ -165,13 +254,32 @@ func (c *Cache) refresh() error {
 		return true
 	}
 
-	_ = scanSpecDirs(c.specDirs, func(path string, priority int, spec *Spec, err error) error {
+	cfg := &scanConfig{
+		validator:  c.validator,
+		trust:      c.trustPolicy,
+		maxVersion: c.maxVersion,
+	}
+	err := scanSpecDirs(c.specDirs, cfg, func(path string, priority int, spec *Spec, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
 		path = filepath.Clean(path)
+		if err == nil && c.signatureKeyDir != "" {
+			if keysErr != nil {
+				err = fmt.Errorf("can't verify CDI Spec %q: %w", path, keysErr)
+			} else {
+				err = verifySpecSignature(spec, keys)
+			}
+		}
 		if err != nil {
+			dirStats[priority].Failed++
+			c.logger.Warn("failed to load CDI Spec", "path", path, "error", err)
 			collectError(fmt.Errorf("failed to load CDI Spec %w", err), path)
 			return nil
 		}
 
+		dirStats[priority].Loaded++
 		vendor := spec.GetVendor()
 		specs[vendor] = append(specs[vendor], spec)
 
@@ -189,44 +297,240 @@ func (c *Cache) refresh() error {
 		return nil
 	})
 
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		c.logger.Warn("CDI Cache refresh aborted", "error", ctxErr)
+		return ctxErr
+	}
+	if err != nil {
+		c.logger.Error("failed to scan CDI Spec directories", "error", err)
+		result = append(result, fmt.Errorf("failed to scan CDI Spec directories: %w", err))
+	}
+
 	for conflict := range conflicts {
+		c.logger.Warn("ignoring conflicting CDI device", "device", conflict)
 		delete(devices, conflict)
 	}
 
 	c.specs = specs
 	c.devices = devices
 	c.errors = specErrors
+	c.refreshPending = false
+
+	c.logger.Debug("refreshed CDI Cache", "specs", len(specs), "devices", len(devices),
+		"errors", len(result))
+
+	stats := &RefreshStats{
+		Duration:  time.Since(start),
+		SpecDirs:  dirStats,
+		Devices:   map[string]int{},
+		Conflicts: len(conflicts),
+		Errors:    len(result),
+	}
+	for _, dev := range devices {
+		stats.Devices[dev.GetSpec().Kind]++
+	}
+	c.metrics.Refreshed(stats)
 
 	return multierror.New(result...)
 }
 
 // RefreshIfRequired triggers a refresh if necessary.
-func (c *Cache) refreshIfRequired(force bool) (bool, error) {
+func (c *Cache) refreshIfRequired(ctx context.Context, force bool) (bool, error) {
 	// We need to refresh if
 	// - it's forced by an explicit call to Refresh() in manual mode
 	// - a missing Spec dir appears (added to watch) in auto-refresh mode
-	if force || (c.autoRefresh && c.watch.update(c.dirErrors)) {
-		return true, c.refresh()
+	// - an earlier refresh for an appeared Spec dir got aborted
+	if c.autoRefresh && c.watch.update(c.dirErrors) {
+		c.refreshPending = true
+	}
+	if force || c.refreshPending {
+		return true, c.refresh(ctx)
 	}
 	return false, nil
 }
 
 // InjectDevices injects the given qualified devices to an OCI Spec. It
 // returns any unresolvable devices and an error if injection fails for
-// any of the devices.
+// any of the devices. Devices can also be requested using patterns, in
+// which case all matching devices are injected in sorted order. Device
+// patterns are described in IsDevicePattern().
 func (c *Cache) InjectDevices(ociSpec *oci.Spec, devices ...string) ([]string, error) {
-	var unresolved []string
+	return c.InjectDevicesContext(context.Background(), ociSpec, devices...)
+}
+
+// InjectDevicesContext is like InjectDevices but takes a context. If
+// the context is canceled or expires before injection, nothing is
+// injected and the context error is returned.
+func (c *Cache) InjectDevicesContext(ctx context.Context, ociSpec *oci.Spec, devices ...string) ([]string, error) {
+	report, err := c.injectDevices(ctx, ociSpec, devices)
+	if err != nil {
+		return report.Unresolved, err
+	}
+	return nil, nil
+}
+
+// InjectDevicesWithReport injects the given qualified devices to an OCI
+// Spec, much like InjectDevices does. Additionally, it returns a report
+// listing every applied edit together with its origin and any entries
+// already present in the OCI Spec which got replaced by the injection.
+// If any of the devices is unresolvable, the report lists the offending
+// devices and nothing is injected.
+func (c *Cache) InjectDevicesWithReport(ociSpec *oci.Spec, devices ...string) (*InjectionReport, error) {
+	return c.injectDevices(context.Background(), ociSpec, devices)
+}
+
+// injectDevices injects devices and reports what was injected.
+func (c *Cache) injectDevices(ctx context.Context, ociSpec *oci.Spec, devices []string) (report *InjectionReport, err error) {
+	start := time.Now()
+	report = &InjectionReport{}
 
 	if ociSpec == nil {
-		return devices, fmt.Errorf("can't inject devices, nil OCI Spec")
+		report.Unresolved = devices
+		return report, fmt.Errorf("can't inject devices, nil OCI Spec")
+	}
+	if err := ctx.Err(); err != nil {
+		return report, err
 	}
 
 	c.Lock()
 	defer c.Unlock()
 
-	c.refreshIfRequired(false)
+	if c.closed {
+		report.Unresolved = devices
+		return report, ErrCacheClosed
+	}
+
+	defer func() {
+		c.metrics.Injected(&InjectionStats{
+			Duration:   time.Since(start),
+			Devices:    len(report.Devices),
+			Unresolved: len(report.Unresolved),
+			Conflicts:  len(report.Conflicts),
+			Failed:     err != nil,
+		})
+	}()
+
+	if _, err := c.refreshIfRequired(ctx, false); err != nil && ctx.Err() != nil {
+		return report, err
+	}
+
+	c.logger.Debug("injecting CDI devices", "devices", devices)
+
+	sets, unresolved, err := c.collectEdits(devices)
+	if err != nil {
+		c.logger.Warn("unresolvable CDI devices", "devices", unresolved)
+		report.Unresolved = unresolved
+		return report, err
+	}
+
+	report.record(ociSpec, sets, c.rootless)
+
+	if c.conflictPolicy != ConflictIgnore {
+		report.Conflicts = findConflicts(report.Edits)
+		for _, conflict := range report.Conflicts {
+			c.logger.Warn("conflicting CDI edits", "conflict", conflict.String())
+		}
+		if len(report.Conflicts) > 0 && c.conflictPolicy == ConflictFail {
+			return report, fmt.Errorf("failed to inject devices: %w",
+				&ConflictError{Conflicts: report.Conflicts})
+		}
+	}
 
-	edits := &ContainerEdits{}
+	edits := mergeEdits(sets)
+
+	if c.admissionPolicy != nil {
+		report.Violations = c.admissionPolicy.Check(report.Devices, edits)
+		if len(report.Violations) > 0 {
+			for _, v := range report.Violations {
+				c.logger.Warn("CDI injection policy violation", "violation", v.String())
+			}
+			return report, fmt.Errorf("failed to inject devices: %w",
+				&PolicyError{Violations: report.Violations})
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+
+	if err := edits.apply(ociSpec, applyConfig{rootless: c.rootless, host: c.host}); err != nil {
+		c.logger.Error("failed to inject CDI devices", "devices", report.Devices, "error", err)
+		return report, fmt.Errorf("failed to inject devices: %w", err)
+	}
+
+	if c.recordProvenance {
+		if err := recordProvenance(ociSpec, report); err != nil {
+			return report, err
+		}
+	}
+
+	c.logger.Debug("injected CDI devices", "devices", report.Devices, "edits", len(report.Edits))
+
+	return report, nil
+}
+
+// ResolveEdits resolves the given qualified devices to the container
+// edits InjectDevices would apply for them, without touching any OCI
+// Spec. The global edits of each Spec are included once, before the
+// edits of the first device from that Spec, in the same order they
+// would get applied. It returns any unresolvable devices and an error
+// if any of the devices fails to resolve.
+//
+// The returned edits are a copy which the caller is free to modify.
+// Missing device node information is not filled in from the host. Use
+// FillMissingInfo() of the Cache on the returned edits if necessary.
+func (c *Cache) ResolveEdits(devices ...string) (*ContainerEdits, []string, error) {
+	return c.ResolveEditsContext(context.Background(), devices...)
+}
+
+// ResolveEditsContext is like ResolveEdits but takes a context. If the
+// context is canceled or expires, the context error is returned.
+func (c *Cache) ResolveEditsContext(ctx context.Context, devices ...string) (*ContainerEdits, []string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if c.closed {
+		return nil, devices, ErrCacheClosed
+	}
+
+	if _, err := c.refreshIfRequired(ctx, false); err != nil && ctx.Err() != nil {
+		return nil, nil, err
+	}
+
+	sets, unresolved, err := c.collectEdits(devices)
+	if err != nil {
+		return nil, unresolved, err
+	}
+
+	return mergeEdits(sets), nil, nil
+}
+
+// FillMissingInfo fills in any missing device node information in the
+// given container edits, like ContainerEdits.FillMissingInfo(), looking
+// up the host devices as configured for the Cache with the WithHostRoot
+// and WithDeviceStat options. This is the information InjectDevices would
+// fill in.
+func (c *Cache) FillMissingInfo(edits *ContainerEdits) error {
+	c.Lock()
+	host := c.host
+	c.Unlock()
+
+	return edits.fillMissingInfoFrom(host)
+}
+
+// collectEdits collects copies of the container edits for the given
+// devices, in the order they get applied during injection.
+func (c *Cache) collectEdits(devices []string) ([]*editSet, []string, error) {
+	var (
+		unresolved []string
+		sets       []*editSet
+	)
+
+	devices, unresolved = c.expandDevices(devices)
 	specs := map[*Spec]struct{}{}
 
 	for _, device := range devices {
@@ -237,21 +541,25 @@ func (c *Cache) InjectDevices(ociSpec *oci.Spec, devices ...string) ([]string, e
 		}
 		if _, ok := specs[d.GetSpec()]; !ok {
 			specs[d.GetSpec()] = struct{}{}
-			edits.Append(d.GetSpec().edits())
+			sets = append(sets, &editSet{
+				scope:  SpecScope,
+				device: d,
+				edits:  d.GetSpec().edits().clone(),
+			})
 		}
-		edits.Append(d.edits())
+		sets = append(sets, &editSet{
+			scope:  DeviceScope,
+			device: d,
+			edits:  d.edits().clone(),
+		})
 	}
 
 	if unresolved != nil {
-		return unresolved, fmt.Errorf("unresolvable CDI devices %s",
+		return nil, unresolved, fmt.Errorf("unresolvable CDI devices %s",
 			strings.Join(unresolved, ", "))
 	}
 
-	if err := edits.Apply(ociSpec); err != nil {
-		return nil, fmt.Errorf("failed to inject devices: %w", err)
-	}
-
-	return nil, nil
+	return sets, nil, nil
 }
 
 // highestPrioritySpecDir returns the Spec directory with highest priority
@@ -279,7 +587,15 @@ func (c *Cache) WriteSpec(raw *cdi.Spec, name string) error {
 		err     error
 	)
 
+	c.Lock()
+	closed := c.closed
 	specDir, prio = c.highestPrioritySpecDir()
+	validator := c.validator
+	c.Unlock()
+
+	if closed {
+		return ErrCacheClosed
+	}
 	if specDir == "" {
 		return errors.New("no Spec directories to write to")
 	}
@@ -289,7 +605,7 @@ func (c *Cache) WriteSpec(raw *cdi.Spec, name string) error {
 		path += defaultSpecExt
 	}
 
-	spec, err = newSpec(raw, path, prio)
+	spec, err = newSpecWithValidator(raw, path, prio, validator)
 	if err != nil {
 		return err
 	}
@@ -308,7 +624,14 @@ func (c *Cache) RemoveSpec(name string) error {
 		err     error
 	)
 
+	c.Lock()
+	closed := c.closed
 	specDir, _ = c.highestPrioritySpecDir()
+	c.Unlock()
+
+	if closed {
+		return ErrCacheClosed
+	}
 	if specDir == "" {
 		return errors.New("no Spec directories to remove from")
 	}
@@ -331,7 +654,7 @@ func (c *Cache) GetDevice(device string) *Device {
 	c.Lock()
 	defer c.Unlock()
 
-	c.refreshIfRequired(false)
+	c.refreshIfRequired(context.Background(), false)
 
 	return c.devices[device]
 }
@@ -343,7 +666,7 @@ func (c *Cache) ListDevices() []string {
 	c.Lock()
 	defer c.Unlock()
 
-	c.refreshIfRequired(false)
+	c.refreshIfRequired(context.Background(), false)
 
 	for name := range c.devices {
 		devices = append(devices, name)
@@ -360,7 +683,7 @@ func (c *Cache) ListVendors() []string {
 	c.Lock()
 	defer c.Unlock()
 
-	c.refreshIfRequired(false)
+	c.refreshIfRequired(context.Background(), false)
 
 	for vendor := range c.specs {
 		vendors = append(vendors, vendor)
@@ -380,7 +703,7 @@ func (c *Cache) ListClasses() []string {
 	c.Lock()
 	defer c.Unlock()
 
-	c.refreshIfRequired(false)
+	c.refreshIfRequired(context.Background(), false)
 
 	for _, specs := range c.specs {
 		for _, spec := range specs {
@@ -400,7 +723,7 @@ func (c *Cache) GetVendorSpecs(vendor string) []*Spec {
 	c.Lock()
 	defer c.Unlock()
 
-	c.refreshIfRequired(false)
+	c.refreshIfRequired(context.Background(), false)
 
 	return c.specs[vendor]
 }
@@ -468,8 +791,18 @@ func (c *Cache) GetSpecDirErrors() map[string]error {
 type watch struct {
 	watcher *fsnotify.Watcher
 	tracked map[string]bool
+	logger  Logger
+	stopCh  chan struct{}
+	doneCh  chan struct{}
 }
 
+// stoppedWatch is returned by stop() if there is nothing to wait for.
+var stoppedWatch = func() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
 // Setup monitoring for the given Spec directories.
 func (w *watch) setup(dirs []string, dirErrors map[string]error) {
 	var (
@@ -483,6 +816,7 @@ func (w *watch) setup(dirs []string, dirErrors map[string]error) {
 
 	w.watcher, err = fsnotify.NewWatcher()
 	if err != nil {
+		w.logger.Error("failed to create CDI Spec directory watcher", "error", err)
 		for _, dir := range dirs {
 			dirErrors[dir] = fmt.Errorf("failed to create watcher: %w", err)
 		}
@@ -492,29 +826,57 @@ func (w *watch) setup(dirs []string, dirErrors map[string]error) {
 	w.update(dirErrors)
 }
 
-// Start watching Spec directories for relevant changes.
-func (w *watch) start(m *sync.Mutex, refresh func() error, dirErrors map[string]error) {
-	go w.watch(w.watcher, m, refresh, dirErrors)
-}
-
-// Stop watching directories.
-func (w *watch) stop() {
+// Start watching Spec directories for relevant changes. The watching
+// goroutine logs using the given logger, captured here, since the watch
+// logger may be reconfigured while the goroutine is running.
+func (w *watch) start(m *sync.Mutex, refresh func() error, dirErrors map[string]error, logger Logger) {
 	if w.watcher == nil {
 		return
 	}
 
-	w.watcher.Close()
+	fsw, stop, done := w.watcher, make(chan struct{}), make(chan struct{})
+	w.stopCh, w.doneCh = stop, done
+
+	go func() {
+		defer close(done)
+		w.watch(fsw, stop, m, refresh, dirErrors, logger)
+	}()
+}
+
+// Stop watching directories. Returns a channel which is closed once the
+// watching goroutine has exited. The caller must hold the lock given to
+// start() and must not wait on the returned channel before releasing it.
+func (w *watch) stop() <-chan struct{} {
+	done := w.doneCh
+	if w.stopCh != nil {
+		close(w.stopCh)
+	}
+	if w.watcher != nil {
+		w.watcher.Close()
+	}
+
+	w.watcher = nil
 	w.tracked = nil
+	w.stopCh = nil
+	w.doneCh = nil
+
+	if done == nil {
+		return stoppedWatch
+	}
+	return done
 }
 
 // Watch Spec directory changes, triggering a refresh if necessary.
-func (w *watch) watch(fsw *fsnotify.Watcher, m *sync.Mutex, refresh func() error, dirErrors map[string]error) {
+func (w *watch) watch(fsw *fsnotify.Watcher, stop <-chan struct{}, m *sync.Mutex, refresh func() error, dirErrors map[string]error, logger Logger) {
 	watch := fsw
 	if watch == nil {
 		return
 	}
 	for {
 		select {
+		case <-stop:
+			return
+
 		case event, ok := <-watch.Events:
 			if !ok {
 				return
@@ -524,24 +886,35 @@ func (w *watch) watch(fsw *fsnotify.Watcher, m *sync.Mutex, refresh func() error
 				continue
 			}
 			if event.Op == fsnotify.Write {
-				if ext := filepath.Ext(event.Name); ext != ".json" && ext != ".yaml" {
+				if ext := filepath.Ext(event.Name); ext != ".json" && ext != ".yaml" && ext != SignatureExt {
 					continue
 				}
 			}
 
+			logger.Debug("CDI Spec directory changed", "path", event.Name, "op", event.Op.String())
+
 			m.Lock()
+			select {
+			case <-stop:
+				m.Unlock()
+				return
+			default:
+			}
 			if event.Op == fsnotify.Remove && w.tracked[event.Name] {
 				w.update(dirErrors, event.Name)
 			} else {
 				w.update(dirErrors)
 			}
-			refresh()
+			if err := refresh(); err != nil {
+				logger.Warn("CDI Cache refresh failed", "error", err)
+			}
 			m.Unlock()
 
-		case _, ok := <-watch.Errors:
+		case err, ok := <-watch.Errors:
 			if !ok {
 				return
 			}
+			logger.Error("CDI Spec directory watch failed", "error", err)
 		}
 	}
 }
@@ -555,6 +928,10 @@ func (w *watch) update(dirErrors map[string]error, removed ...string) bool {
 		update bool
 	)
 
+	if w.watcher == nil {
+		return false
+	}
+
 	for dir, ok = range w.tracked {
 		if ok {
 			continue
@@ -562,6 +939,7 @@ func (w *watch) update(dirErrors map[string]error, removed ...string) bool {
 
 		err = w.watcher.Add(dir)
 		if err == nil {
+			w.logger.Info("watching CDI Spec directory", "path", dir)
 			w.tracked[dir] = true
 			delete(dirErrors, dir)
 			update = true
@@ -572,6 +950,7 @@ func (w *watch) update(dirErrors map[string]error, removed ...string) bool {
 	}
 
 	for _, dir = range removed {
+		w.logger.Warn("CDI Spec directory removed", "path", dir)
 		w.tracked[dir] = false
 		dirErrors[dir] = errors.New("directory removed")
 		update = true
@@ -17,10 +17,12 @@
 package cdi
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -183,6 +185,7 @@ devices:
 				filepath.Join(dir, "etc"),
 				filepath.Join(dir, "run")),
 			)
+			defer cache.Close()
 
 			if len(tc.dirErrors) != 0 {
 				for specDir = range tc.dirErrors {
@@ -555,6 +558,7 @@ devices:
 						}
 						cache, err = NewCache(opts...)
 						require.NotNil(t, cache)
+						defer cache.Close()
 					} else {
 						err = updateSpecDirs(t, dir, update.etc, update.run)
 						if err != nil {
@@ -792,6 +796,7 @@ devices:
 			)
 			require.NoError(t, err)
 			require.NotNil(t, cache)
+			defer cache.Close()
 
 			go injector()
 			go updater()
@@ -1179,6 +1184,7 @@ devices:
 			)
 			require.Nil(t, err)
 			require.NotNil(t, cache)
+			defer cache.Close()
 
 			unresolved, err := cache.InjectDevices(tc.ociSpec, tc.devices...)
 			if len(tc.unresolved) != 0 {
@@ -1194,6 +1200,372 @@ devices:
 	}
 }
 
+func TestInjectDevicesWithReport(t *testing.T) {
+	const (
+		vendor1 = `
+cdiVersion: "0.3.0"
+kind:       "vendor1.com/device"
+containerEdits:
+  env:
+  - VENDOR1_SPEC_VAR1=VAL1
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "ORIG_VAR1=OVERRIDE"
+      deviceNodes:
+      - path: "/dev/vendor1-dev1"
+        type: b
+        major: 10
+        minor: 1
+  - name: "dev2"
+    containerEdits:
+      mounts:
+      - hostPath: "/opt/vendor1/lib"
+        containerPath: "/usr/lib/vendor1"
+      hooks:
+      - hookName: prestart
+        path: "/usr/local/bin/vendor1-hook"
+`
+	)
+
+	dir, err := createSpecDirs(t, map[string]string{"vendor1.yaml": vendor1}, nil)
+	require.NoError(t, err)
+
+	cache, err := NewCache(
+		WithSpecDirs(
+			filepath.Join(dir, "etc"),
+			filepath.Join(dir, "run"),
+		),
+		WithAutoRefresh(false),
+	)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	specPath := filepath.Join(dir, "etc", "vendor1.yaml")
+	ociSpec := &oci.Spec{
+		Process: &oci.Process{
+			Env: []string{
+				"ORIG_VAR1=VAL1",
+			},
+		},
+		Mounts: []oci.Mount{
+			{
+				Source:      "/usr/lib/vendor1",
+				Destination: "/usr/lib/vendor1",
+			},
+		},
+		Linux: &oci.Linux{
+			Devices: []oci.LinuxDevice{
+				{
+					Path: "/dev/vendor1-dev1",
+				},
+			},
+		},
+	}
+
+	report, err := cache.InjectDevicesWithReport(ociSpec,
+		"vendor1.com/device=dev1",
+		"vendor1.com/device=dev2",
+	)
+	require.NoError(t, err)
+	require.Equal(t,
+		&InjectionReport{
+			Devices: []string{
+				"vendor1.com/device=dev1",
+				"vendor1.com/device=dev2",
+			},
+			Edits: []*AppliedEdit{
+				{
+					Kind:   EnvEdit,
+					Scope:  SpecScope,
+					Device: "vendor1.com/device=dev1",
+					Spec:   specPath,
+					Env:    "VENDOR1_SPEC_VAR1=VAL1",
+				},
+				{
+					Kind:   EnvEdit,
+					Scope:  DeviceScope,
+					Device: "vendor1.com/device=dev1",
+					Spec:   specPath,
+					Env:    "ORIG_VAR1=OVERRIDE",
+				},
+				{
+					Kind:   DeviceNodeEdit,
+					Scope:  DeviceScope,
+					Device: "vendor1.com/device=dev1",
+					Spec:   specPath,
+					DeviceNode: &cdi.DeviceNode{
+						Path:     "/dev/vendor1-dev1",
+						HostPath: "/dev/vendor1-dev1",
+						Type:     "b",
+						Major:    10,
+						Minor:    1,
+					},
+				},
+				{
+					Kind:   HookEdit,
+					Scope:  DeviceScope,
+					Device: "vendor1.com/device=dev2",
+					Spec:   specPath,
+					Hook: &cdi.Hook{
+						HookName: "prestart",
+						Path:     "/usr/local/bin/vendor1-hook",
+					},
+				},
+				{
+					Kind:   MountEdit,
+					Scope:  DeviceScope,
+					Device: "vendor1.com/device=dev2",
+					Spec:   specPath,
+					Mount: &cdi.Mount{
+						HostPath:      "/opt/vendor1/lib",
+						ContainerPath: "/usr/lib/vendor1",
+					},
+				},
+			},
+			Replaced: []*ReplacedEntry{
+				{
+					Kind:   EnvEdit,
+					Device: "vendor1.com/device=dev1",
+					Spec:   specPath,
+					Env:    "ORIG_VAR1=VAL1",
+				},
+				{
+					Kind:   DeviceNodeEdit,
+					Device: "vendor1.com/device=dev1",
+					Spec:   specPath,
+					DeviceNode: &oci.LinuxDevice{
+						Path: "/dev/vendor1-dev1",
+					},
+				},
+				{
+					Kind:   MountEdit,
+					Device: "vendor1.com/device=dev2",
+					Spec:   specPath,
+					Mount: &oci.Mount{
+						Source:      "/usr/lib/vendor1",
+						Destination: "/usr/lib/vendor1",
+					},
+				},
+			},
+		},
+		report,
+	)
+
+	data, err := json.Marshal(report)
+	require.NoError(t, err)
+	decoded := &InjectionReport{}
+	require.NoError(t, json.Unmarshal(data, decoded))
+	require.Equal(t, report, decoded)
+
+	report, err = cache.InjectDevicesWithReport(&oci.Spec{}, "vendor1.com/device=dev3")
+	require.Error(t, err)
+	require.Equal(t, []string{"vendor1.com/device=dev3"}, report.Unresolved)
+	require.Empty(t, report.Edits)
+}
+
+func TestResolveEdits(t *testing.T) {
+	type specDirs struct {
+		etc map[string]string
+		run map[string]string
+	}
+	type testCase struct {
+		name        string
+		cdiSpecs    specDirs
+		devices     []string
+		fill        bool
+		result      *ContainerEdits
+		unresolved  []string
+		expectedErr error
+	}
+	for _, tc := range []*testCase{
+		{
+			name: "spec and device edits, in injection order",
+			cdiSpecs: specDirs{
+				etc: map[string]string{
+					"vendor1.yaml": `
+cdiVersion: "0.3.0"
+kind:       "vendor1.com/device"
+containerEdits:
+  env:
+  - VENDOR1_SPEC_VAR1=VAL1
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV1=VAL1"
+      deviceNodes:
+      - path: "/dev/vendor1-dev1"
+        type: b
+        major: 10
+        minor: 1
+  - name: "dev2"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV2=VAL2"
+      mounts:
+      - hostPath: "/opt/vendor1/lib"
+        containerPath: "/usr/lib/vendor1"
+        options:
+        - ro
+`,
+					"vendor2.yaml": `
+cdiVersion: "0.3.0"
+kind:       "vendor2.com/device"
+containerEdits:
+  env:
+  - VENDOR2_SPEC_VAR1=VAL1
+devices:
+  - name: "dev1"
+    containerEdits:
+      hooks:
+      - hookName: prestart
+        path: "/usr/local/bin/vendor2-hook"
+`,
+				},
+			},
+			devices: []string{
+				"vendor1.com/device=dev2",
+				"vendor2.com/device=dev1",
+				"vendor1.com/device=dev1",
+			},
+			result: &ContainerEdits{
+				ContainerEdits: &cdi.ContainerEdits{
+					Env: []string{
+						"VENDOR1_SPEC_VAR1=VAL1",
+						"VENDOR1_DEV2=VAL2",
+						"VENDOR2_SPEC_VAR1=VAL1",
+						"VENDOR1_DEV1=VAL1",
+					},
+					DeviceNodes: []*cdi.DeviceNode{
+						{
+							Path:  "/dev/vendor1-dev1",
+							Type:  "b",
+							Major: 10,
+							Minor: 1,
+						},
+					},
+					Hooks: []*cdi.Hook{
+						{
+							HookName: "prestart",
+							Path:     "/usr/local/bin/vendor2-hook",
+						},
+					},
+					Mounts: []*cdi.Mount{
+						{
+							HostPath:      "/opt/vendor1/lib",
+							ContainerPath: "/usr/lib/vendor1",
+							Options:       []string{"ro"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "missing device info filled in",
+			cdiSpecs: specDirs{
+				etc: map[string]string{
+					"vendor1.yaml": `
+cdiVersion: "0.3.0"
+kind:       "vendor1.com/device"
+devices:
+  - name: "null"
+    containerEdits:
+      deviceNodes:
+      - path: "/dev/null"
+`,
+				},
+			},
+			devices: []string{
+				"vendor1.com/device=null",
+			},
+			fill: true,
+			result: &ContainerEdits{
+				ContainerEdits: &cdi.ContainerEdits{
+					DeviceNodes: []*cdi.DeviceNode{
+						{
+							Path:     "/dev/null",
+							HostPath: "/dev/null",
+							Type:     "c",
+							Major:    1,
+							Minor:    3,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "non-existent device",
+			cdiSpecs: specDirs{
+				etc: map[string]string{
+					"vendor1.yaml": `
+cdiVersion: "0.3.0"
+kind:       "vendor1.com/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR1_VAR1=VAL1"
+`,
+				},
+			},
+			devices: []string{
+				"vendor1.com/device=dev1",
+				"vendor1.com/device=dev2",
+			},
+			unresolved: []string{
+				"vendor1.com/device=dev2",
+			},
+			expectedErr: errors.New("unresolvable CDI devices vendor1.com/device=dev2"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := createSpecDirs(t, tc.cdiSpecs.etc, tc.cdiSpecs.run)
+			require.NoError(t, err)
+
+			cache, err := NewCache(
+				WithSpecDirs(
+					filepath.Join(dir, "etc"),
+					filepath.Join(dir, "run"),
+				),
+				WithAutoRefresh(false),
+			)
+			require.NoError(t, err)
+			defer cache.Close()
+
+			edits, unresolved, err := cache.ResolveEdits(tc.devices...)
+			if len(tc.unresolved) != 0 {
+				require.Equal(t, tc.expectedErr, err)
+				require.Equal(t, tc.unresolved, unresolved)
+				require.Nil(t, edits)
+				return
+			}
+			require.NoError(t, err)
+			require.Nil(t, unresolved)
+
+			if tc.fill {
+				require.NoError(t, edits.FillMissingInfo())
+			}
+			require.Equal(t, tc.result, edits)
+
+			// the returned edits must not alias the cached ones
+			for _, d := range edits.DeviceNodes {
+				d.Path = "/dev/modified"
+			}
+			for _, m := range edits.Mounts {
+				m.Options[0] = "modified"
+			}
+			again, _, err := cache.ResolveEdits(tc.devices...)
+			require.NoError(t, err)
+			if tc.fill {
+				require.NoError(t, again.FillMissingInfo())
+			}
+			require.Equal(t, tc.result, again)
+		})
+	}
+}
+
 func TestListVendorsAndClasses(t *testing.T) {
 	type specDirs struct {
 		etc map[string]string
@@ -1418,6 +1790,7 @@ devices:
 			)
 			require.Nil(t, err)
 			require.NotNil(t, cache)
+			defer cache.Close()
 
 			vendors := cache.ListVendors()
 			require.Equal(t, tc.vendors, vendors)
@@ -1576,6 +1949,7 @@ containerEdits:
 
 				require.NoError(t, err)
 				require.NotNil(t, cache)
+				defer cache.Close()
 
 				etc = map[string]string{}
 				for name, data := range tc.etc {
@@ -1606,6 +1980,7 @@ containerEdits:
 			)
 			require.NoError(t, err)
 			require.NotNil(t, cache)
+			defer cache.Close()
 
 			other, err = NewCache(
 				WithSpecDirs(
@@ -1615,6 +1990,7 @@ containerEdits:
 			)
 			require.NoError(t, err)
 			require.NotNil(t, other)
+			defer other.Close()
 
 			cSpecs := map[string]*cdi.Spec{}
 			for _, vendor := range cache.ListVendors() {
@@ -1801,6 +2177,7 @@ devices:
 
 			require.NoError(t, err)
 			require.NotNil(t, cache)
+			defer cache.Close()
 
 			for idx, data := range tc.specs {
 				var (
@@ -1881,3 +2258,182 @@ func updateSpecDirs(t *testing.T, dir string, etc, run map[string]string) error
 func int64ptr(v int64) *int64 {
 	return &v
 }
+
+func TestCacheClose(t *testing.T) {
+	const (
+		vendor1 = `
+cdiVersion: "0.3.0"
+kind:       "vendor1.com/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV1=VAL1"
+`
+	)
+
+	// count the goroutines currently watching Spec directories
+	countWatchers := func() int {
+		buf := make([]byte, 1<<20)
+		for {
+			n := runtime.Stack(buf, true)
+			if n < len(buf) {
+				return strings.Count(string(buf[:n]), "(*watch).watch(")
+			}
+			buf = make([]byte, 2*len(buf))
+		}
+	}
+	waitWatchers := func(count int) {
+		require.Eventually(t, func() bool { return countWatchers() == count },
+			time.Second, 10*time.Millisecond)
+	}
+
+	dir, err := createSpecDirs(t, map[string]string{"vendor1.yaml": vendor1}, nil)
+	require.NoError(t, err)
+
+	before := countWatchers()
+
+	cache, err := NewCache(
+		WithSpecDirs(
+			filepath.Join(dir, "etc"),
+			filepath.Join(dir, "run"),
+		),
+		WithAutoRefresh(true),
+	)
+	require.NoError(t, err)
+	waitWatchers(before + 1)
+
+	// reconfiguring must not leave the previous watcher running
+	for i := 0; i < 5; i++ {
+		require.NoError(t, cache.Configure(WithAutoRefresh(true)))
+		waitWatchers(before + 1)
+	}
+	require.NoError(t, cache.Configure(WithAutoRefresh(false)))
+	waitWatchers(before)
+	require.NoError(t, cache.Configure(WithAutoRefresh(true)))
+	waitWatchers(before + 1)
+
+	require.Equal(t, []string{"vendor1.com/device=dev1"}, cache.ListDevices())
+
+	require.NoError(t, cache.Close())
+	require.Equal(t, before, countWatchers())
+	require.NoError(t, cache.Close())
+
+	require.ErrorIs(t, cache.Refresh(), ErrCacheClosed)
+	require.ErrorIs(t, cache.Configure(WithAutoRefresh(true)), ErrCacheClosed)
+	require.Equal(t, before, countWatchers())
+
+	ociSpec := &oci.Spec{}
+	unresolved, err := cache.InjectDevices(ociSpec, "vendor1.com/device=dev1")
+	require.ErrorIs(t, err, ErrCacheClosed)
+	require.Equal(t, []string{"vendor1.com/device=dev1"}, unresolved)
+	require.Equal(t, &oci.Spec{}, ociSpec)
+
+	_, _, err = cache.ResolveEdits("vendor1.com/device=dev1")
+	require.ErrorIs(t, err, ErrCacheClosed)
+
+	_, err = cache.EjectDevices(ociSpec, "vendor1.com/device=dev1")
+	require.ErrorIs(t, err, ErrCacheClosed)
+
+	require.ErrorIs(t, cache.RemoveSpec("vendor1.yaml"), ErrCacheClosed)
+}
+
+func TestCacheSpecValidator(t *testing.T) {
+	const (
+		vendor1 = `
+cdiVersion: "0.3.0"
+kind:       "vendor1.com/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV1=VAL1"
+`
+		vendor2 = `
+cdiVersion: "0.3.0"
+kind:       "vendor2.com/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR2_DEV1=VAL1"
+`
+	)
+
+	var calls []string
+	rejectVendor := func(vendor string) func(*cdi.Spec) error {
+		return func(raw *cdi.Spec) error {
+			calls = append(calls, vendor+":"+raw.Kind)
+			if strings.HasPrefix(raw.Kind, vendor+"/") {
+				return fmt.Errorf("vendor %q not allowed", vendor)
+			}
+			return nil
+		}
+	}
+
+	dir, err := createSpecDirs(t, map[string]string{
+		"vendor1.yaml": vendor1,
+		"vendor2.yaml": vendor2,
+	}, nil)
+	require.NoError(t, err)
+
+	cache, err := NewCache(
+		WithSpecDirs(
+			filepath.Join(dir, "etc"),
+			filepath.Join(dir, "run"),
+		),
+		WithAutoRefresh(false),
+		WithSpecValidator(rejectVendor("vendor3.com"), nil, rejectVendor("vendor2.com")),
+	)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	require.Equal(t, []string{"vendor1.com/device=dev1"}, cache.ListDevices())
+	require.Equal(t, []string{
+		"vendor3.com:vendor1.com/device",
+		"vendor2.com:vendor1.com/device",
+		"vendor3.com:vendor2.com/device",
+		"vendor2.com:vendor2.com/device",
+	}, calls)
+
+	// the validators of one Cache do not affect another one
+	other, err := NewCache(
+		WithSpecDirs(
+			filepath.Join(dir, "etc"),
+			filepath.Join(dir, "run"),
+		),
+		WithAutoRefresh(false),
+	)
+	require.NoError(t, err)
+	defer other.Close()
+	require.Equal(t, []string{"vendor1.com/device=dev1", "vendor2.com/device=dev1"}, other.ListDevices())
+
+	// validators are used when writing Specs, too
+	calls = nil
+	raw := &cdi.Spec{
+		Version: "0.3.0",
+		Kind:    "vendor3.com/device",
+		Devices: []cdi.Device{
+			{
+				Name: "dev1",
+				ContainerEdits: cdi.ContainerEdits{
+					Env: []string{"VENDOR3_DEV1=VAL1"},
+				},
+			},
+		},
+	}
+	err = cache.WriteSpec(raw, "vendor3.yaml")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `vendor "vendor3.com" not allowed`)
+	require.Equal(t, []string{"vendor3.com:vendor3.com/device"}, calls)
+	require.NoError(t, other.WriteSpec(raw, "vendor3.yaml"))
+
+	// without validators the global one is used again
+	require.NoError(t, cache.Configure(WithSpecValidator()))
+	require.NoError(t, cache.Refresh())
+	require.Equal(t, []string{
+		"vendor1.com/device=dev1",
+		"vendor2.com/device=dev1",
+		"vendor3.com/device=dev1",
+	}, cache.ListDevices())
+}
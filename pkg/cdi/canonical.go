@@ -0,0 +1,130 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	cdi "tags.cncf.io/container-device-interface/specs-go"
+)
+
+// CanonicalSpec returns a canonical copy of the Spec data. Devices are
+// sorted by name, host and container paths are cleaned, and empty
+// annotations are dropped. Annotations are always encoded sorted by
+// key. The order of environment variables, device nodes, mounts, and
+// hooks is significant, so it is preserved. If minimalVersion is true,
+// the version is set to the lowest version compatible with the content
+// of the Spec.
+func CanonicalSpec(raw *cdi.Spec, minimalVersion bool) *cdi.Spec {
+	spec := CloneSpec(raw)
+
+	if len(spec.Annotations) == 0 {
+		spec.Annotations = nil
+	}
+	canonicalEdits(&spec.ContainerEdits)
+	for i := range spec.Devices {
+		d := &spec.Devices[i]
+		if len(d.Annotations) == 0 {
+			d.Annotations = nil
+		}
+		canonicalEdits(&d.ContainerEdits)
+	}
+	sort.SliceStable(spec.Devices, func(i, j int) bool {
+		return spec.Devices[i].Name < spec.Devices[j].Name
+	})
+
+	if minimalVersion {
+		spec.Version = validSpecVersions.requiredVersion(spec).String()
+	}
+
+	return spec
+}
+
+// FormatSpecFile rewrites the given Spec file in canonical form, as
+// produced by CanonicalSpec. The encoding of the file is preserved. If
+// check is true, the file is not rewritten. Whether the file was, or
+// in check mode would have been, changed is returned. A signed Spec file
+// is not rewritten if that would invalidate its signature, instead an
+// error wrapping ErrSignedSpec is returned.
+func FormatSpecFile(path string, minimalVersion, check bool) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	spec, err := ReadSpec(path, 0)
+	if err != nil {
+		return false, err
+	}
+
+	raw := spec.Spec
+	spec.Spec = CanonicalSpec(raw, minimalVersion)
+	canonical, err := spec.encode()
+	if err != nil {
+		return false, err
+	}
+
+	if bytes.Equal(data, canonical) {
+		return false, nil
+	}
+
+	if !check {
+		if err := checkSignedRewrite(path, raw, spec.Spec); err != nil {
+			return false, err
+		}
+		if err := spec.write(true); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// canonicalEdits cleans the paths of the given container edits.
+func canonicalEdits(edits *cdi.ContainerEdits) {
+	for _, d := range edits.DeviceNodes {
+		d.Path = cleanContainerPath(d.Path)
+		d.HostPath = cleanHostPath(d.HostPath)
+	}
+	for _, m := range edits.Mounts {
+		m.HostPath = cleanHostPath(m.HostPath)
+		m.ContainerPath = cleanContainerPath(m.ContainerPath)
+	}
+	for _, h := range edits.Hooks {
+		h.Path = cleanHostPath(h.Path)
+	}
+}
+
+// cleanHostPath cleans a non-empty host path.
+func cleanHostPath(p string) string {
+	if p == "" {
+		return p
+	}
+	return filepath.Clean(p)
+}
+
+// cleanContainerPath cleans a non-empty container path.
+func cleanContainerPath(p string) string {
+	if p == "" {
+		return p
+	}
+	return path.Clean(p)
+}
@@ -0,0 +1,189 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+	cdi "tags.cncf.io/container-device-interface/specs-go"
+)
+
+func TestCanonicalSpec(t *testing.T) {
+	var raw *cdi.Spec
+	require.NoError(t, yaml.Unmarshal([]byte(`
+cdiVersion: "0.6.0"
+kind: vendor.com/device
+annotations: {}
+containerEdits:
+  hooks:
+    - hookName: createContainer
+      path: /usr/bin//hook
+devices:
+  - name: dev1
+    annotations:
+      b: "2"
+      a: "1"
+    containerEdits:
+      env: ["FOO=bar", "BAR=foo"]
+      deviceNodes:
+        - path: /dev/./dev1
+          hostPath: /dev/../dev/vdev1
+  - name: dev0
+    containerEdits:
+      mounts:
+        - hostPath: /lib/
+          containerPath: /usr/lib/
+          options: ["bind"]
+`), &raw))
+	orig, err := yaml.Marshal(raw)
+	require.NoError(t, err)
+
+	spec := CanonicalSpec(raw, false)
+
+	expected := `cdiVersion: 0.6.0
+containerEdits:
+  hooks:
+  - hookName: createContainer
+    path: /usr/bin/hook
+devices:
+- containerEdits:
+    mounts:
+    - containerPath: /usr/lib
+      hostPath: /lib
+      options:
+      - bind
+  name: dev0
+- annotations:
+    a: "1"
+    b: "2"
+  containerEdits:
+    deviceNodes:
+    - hostPath: /dev/vdev1
+      path: /dev/dev1
+    env:
+    - FOO=bar
+    - BAR=foo
+  name: dev1
+kind: vendor.com/device
+`
+	data, err := yaml.Marshal(spec)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(data))
+
+	// the original Spec data must be left intact
+	data, err = yaml.Marshal(raw)
+	require.NoError(t, err)
+	require.Equal(t, orig, data)
+
+	// canonicalization is idempotent
+	require.Equal(t, spec, CanonicalSpec(spec, false))
+
+	// device annotations require 0.6.0, a device node hostPath 0.5.0
+	require.Equal(t, "0.6.0", CanonicalSpec(raw, true).Version)
+	raw.Devices[0].Annotations = nil
+	require.Equal(t, "0.5.0", CanonicalSpec(raw, true).Version)
+}
+
+func TestFormatSpecFile(t *testing.T) {
+	const spec = `
+cdiVersion: "0.6.0"
+kind: vendor.com/device
+devices:
+  - name: dev1
+    containerEdits:
+      env: ["FOO=bar"]
+  - name: dev0
+    containerEdits:
+      env: ["FOO=bar"]
+`
+	dir := t.TempDir()
+
+	for _, ext := range []string{".yaml", ".json"} {
+		path := filepath.Join(dir, "spec"+ext)
+		raw := []byte(spec)
+		if ext == ".json" {
+			var err error
+			raw, err = yaml.YAMLToJSON(raw)
+			require.NoError(t, err)
+		}
+		require.NoError(t, os.WriteFile(path, raw, 0o644))
+
+		changed, err := FormatSpecFile(path, true, true)
+		require.NoError(t, err)
+		require.True(t, changed)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, raw, data, "file changed in check mode")
+
+		changed, err = FormatSpecFile(path, true, false)
+		require.NoError(t, err)
+		require.True(t, changed)
+
+		formatted, err := ReadSpec(path, 0)
+		require.NoError(t, err)
+		require.Equal(t, "0.3.0", formatted.Version)
+		require.Equal(t, "dev0", formatted.Devices[0].Name)
+
+		data, err = os.ReadFile(path)
+		require.NoError(t, err)
+		if ext == ".json" {
+			require.Equal(t, byte('{'), data[0])
+		} else {
+			require.Equal(t, "---\n", string(data[:4]))
+		}
+
+		changed, err = FormatSpecFile(path, true, true)
+		require.NoError(t, err)
+		require.False(t, changed)
+	}
+
+	_, err := FormatSpecFile(filepath.Join(dir, "missing.yaml"), false, true)
+	require.Error(t, err)
+}
+
+func TestFormatSignedSpecFile(t *testing.T) {
+	const spec = `
+cdiVersion: "0.6.0"
+kind: vendor.com/device
+devices:
+  - name: dev1
+    containerEdits:
+      env: ["FOO=bar"]
+`
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(spec), 0o644))
+	require.NoError(t, os.WriteFile(SignatureFile(path), []byte("signature\n"), 0o644))
+
+	// lowering the version would invalidate the signature
+	changed, err := FormatSpecFile(path, true, true)
+	require.NoError(t, err)
+	require.True(t, changed)
+	_, err = FormatSpecFile(path, true, false)
+	require.ErrorIs(t, err, ErrSignedSpec)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, spec, string(data))
+
+	// reformatting alone keeps the signature valid
+	changed, err = FormatSpecFile(path, false, false)
+	require.NoError(t, err)
+	require.True(t, changed)
+}
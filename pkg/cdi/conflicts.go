@@ -0,0 +1,260 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"tags.cncf.io/container-device-interface/specs-go"
+)
+
+// ConflictPolicy determines how conflicting edits are treated during
+// device injection.
+type ConflictPolicy string
+
+const (
+	// ConflictIgnore ignores conflicts. The last conflicting edit applied
+	// takes effect. This is the default policy.
+	ConflictIgnore ConflictPolicy = "ignore"
+	// ConflictWarn reports conflicts but proceeds with injection.
+	ConflictWarn ConflictPolicy = "warn"
+	// ConflictFail fails injection if any conflicts are detected.
+	ConflictFail ConflictPolicy = "fail"
+)
+
+// WithConflictPolicy returns an option to control how conflicting edits
+// of co-injected devices are treated. With ConflictWarn conflicts are
+// listed in the InjectionReport. With ConflictFail injection fails with
+// a *ConflictError if any conflicts are detected.
+func WithConflictPolicy(policy ConflictPolicy) Option {
+	return func(c *Cache) error {
+		switch policy {
+		case ConflictIgnore, ConflictWarn, ConflictFail:
+		case "":
+			policy = ConflictIgnore
+		default:
+			return fmt.Errorf("invalid conflict policy %q", policy)
+		}
+		c.conflictPolicy = policy
+		return nil
+	}
+}
+
+// EditConflict describes conflicting edits for the same container
+// entity: a mount destination, a device node path, an environment
+// variable key, or a duplicate hook. Values lists the conflicting
+// values, Devices the corresponding originating devices, if known.
+type EditConflict struct {
+	Kind    EditKind `json:"kind"`
+	Key     string   `json:"key"`
+	Devices []string `json:"devices,omitempty"`
+	Values  []string `json:"values"`
+}
+
+// String returns a human-readable description of the conflict.
+func (c *EditConflict) String() string {
+	var values []string
+	for i, v := range c.Values {
+		if i < len(c.Devices) && c.Devices[i] != "" {
+			v += " (" + c.Devices[i] + ")"
+		}
+		values = append(values, v)
+	}
+	if c.Kind == HookEdit {
+		return fmt.Sprintf("duplicate %s %s: %s", c.Kind, c.Key, strings.Join(values, ", "))
+	}
+	return fmt.Sprintf("conflicting %s %s: %s", c.Kind, c.Key, strings.Join(values, ", "))
+}
+
+// ConflictError is the error returned when injection fails because of
+// conflicting edits.
+type ConflictError struct {
+	Conflicts []*EditConflict
+}
+
+// Error returns the error message for the conflicts.
+func (e *ConflictError) Error() string {
+	var msgs []string
+	for _, c := range e.Conflicts {
+		msgs = append(msgs, c.String())
+	}
+	return "conflicting CDI edits: " + strings.Join(msgs, "; ")
+}
+
+// Conflicts returns any conflicts among these edits. These are mounts
+// with the same container path but different host paths, types or
+// options, device nodes with the same path but different host paths
+// or device numbers, environment variables with the same key but with
+// different values, and duplicate hooks.
+func (e *ContainerEdits) Conflicts() []*EditConflict {
+	if e == nil || e.ContainerEdits == nil {
+		return nil
+	}
+
+	report := &InjectionReport{}
+	for _, env := range e.Env {
+		report.Edits = append(report.Edits, &AppliedEdit{Kind: EnvEdit, Env: env})
+	}
+	for _, d := range e.DeviceNodes {
+		report.Edits = append(report.Edits, &AppliedEdit{Kind: DeviceNodeEdit, DeviceNode: d})
+	}
+	for _, h := range e.Hooks {
+		report.Edits = append(report.Edits, &AppliedEdit{Kind: HookEdit, Hook: h})
+	}
+	for _, m := range e.Mounts {
+		report.Edits = append(report.Edits, &AppliedEdit{Kind: MountEdit, Mount: m})
+	}
+
+	return findConflicts(report.Edits)
+}
+
+// findConflicts finds conflicts among the given applied edits.
+func findConflicts(edits []*AppliedEdit) []*EditConflict {
+	var (
+		keys   []string
+		groups = map[string][]*AppliedEdit{}
+	)
+
+	for _, e := range edits {
+		key := e.conflictKey()
+		if key == "" {
+			continue
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], e)
+	}
+
+	var conflicts []*EditConflict
+	for _, key := range keys {
+		group := groups[key]
+		if !hasConflict(group) {
+			continue
+		}
+		c := &EditConflict{
+			Kind: group[0].Kind,
+			Key:  strings.SplitN(key, ":", 2)[1],
+		}
+		for _, e := range group {
+			c.Devices = append(c.Devices, e.Device)
+			c.Values = append(c.Values, e.conflictValue())
+		}
+		if strings.Join(c.Devices, "") == "" {
+			c.Devices = nil
+		}
+		conflicts = append(conflicts, c)
+	}
+
+	return conflicts
+}
+
+// hasConflict checks if the given edits for the same key conflict.
+func hasConflict(edits []*AppliedEdit) bool {
+	if len(edits) < 2 {
+		return false
+	}
+	if edits[0].Kind == HookEdit {
+		return true
+	}
+	for i, a := range edits {
+		for _, b := range edits[i+1:] {
+			if a.conflictsWith(b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// conflictKey returns the key used to group potentially conflicting edits.
+func (e *AppliedEdit) conflictKey() string {
+	switch {
+	case e.Kind == EnvEdit:
+		return string(EnvEdit) + ":" + envKey(e.Env)
+	case e.Kind == DeviceNodeEdit && e.DeviceNode != nil:
+		return string(DeviceNodeEdit) + ":" + e.DeviceNode.Path
+	case e.Kind == MountEdit && e.Mount != nil:
+		return string(MountEdit) + ":" + e.Mount.ContainerPath
+	case e.Kind == HookEdit && e.Hook != nil:
+		return string(HookEdit) + ":" + e.Hook.HookName + " " +
+			strings.Join(append([]string{e.Hook.Path}, e.Hook.Args...), " ")
+	}
+	return ""
+}
+
+// conflictValue returns the value of the edit for conflict reporting.
+func (e *AppliedEdit) conflictValue() string {
+	switch e.Kind {
+	case EnvEdit:
+		return envValue(e.Env)
+	case DeviceNodeEdit:
+		d := e.DeviceNode
+		if d.Type == "" {
+			return deviceHostPath(d)
+		}
+		return fmt.Sprintf("%s (%s %d:%d)", deviceHostPath(d), d.Type, d.Major, d.Minor)
+	case MountEdit:
+		m := e.Mount
+		value := m.HostPath
+		if m.Type != "" {
+			value += " type " + m.Type
+		}
+		if len(m.Options) > 0 {
+			value += " options " + strings.Join(m.Options, ",")
+		}
+		return value
+	case HookEdit:
+		return e.Hook.Path
+	}
+	return ""
+}
+
+// conflictsWith checks if two edits with the same key conflict.
+func (e *AppliedEdit) conflictsWith(o *AppliedEdit) bool {
+	switch e.Kind {
+	case EnvEdit:
+		return e.Env != o.Env
+	case DeviceNodeEdit:
+		a, b := e.DeviceNode, o.DeviceNode
+		if deviceHostPath(a) != deviceHostPath(b) {
+			return true
+		}
+		if a.Type != "" && b.Type != "" && a.Type != b.Type {
+			return true
+		}
+		if a.Major != 0 && b.Major != 0 && (a.Major != b.Major || a.Minor != b.Minor) {
+			return true
+		}
+		return false
+	case MountEdit:
+		a, b := e.Mount, o.Mount
+		return a.HostPath != b.HostPath || a.Type != b.Type ||
+			!reflect.DeepEqual(a.Options, b.Options)
+	}
+	return false
+}
+
+// deviceHostPath returns the host path of a device node.
+func deviceHostPath(d *specs.DeviceNode) string {
+	if d.HostPath != "" {
+		return d.HostPath
+	}
+	return d.Path
+}
@@ -0,0 +1,198 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	oci "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+	cdi "tags.cncf.io/container-device-interface/specs-go"
+)
+
+func TestContainerEditsConflicts(t *testing.T) {
+	type testCase struct {
+		name      string
+		edits     *cdi.ContainerEdits
+		conflicts []*EditConflict
+	}
+	for _, tc := range []*testCase{
+		{
+			name: "no edits",
+		},
+		{
+			name: "identical edits do not conflict",
+			edits: &cdi.ContainerEdits{
+				Env: []string{"FOO=BAR", "FOO=BAR"},
+				DeviceNodes: []*cdi.DeviceNode{
+					{Path: "/dev/foo"},
+					{Path: "/dev/foo", HostPath: "/dev/foo", Type: "c", Major: 1, Minor: 2},
+				},
+				Mounts: []*cdi.Mount{
+					{HostPath: "/opt/foo", ContainerPath: "/foo"},
+					{HostPath: "/opt/foo", ContainerPath: "/foo"},
+				},
+			},
+		},
+		{
+			name: "conflicting env",
+			edits: &cdi.ContainerEdits{
+				Env: []string{"FOO=BAR", "BAR=FOO", "FOO=XYZZY"},
+			},
+			conflicts: []*EditConflict{
+				{
+					Kind:   EnvEdit,
+					Key:    "FOO",
+					Values: []string{"BAR", "XYZZY"},
+				},
+			},
+		},
+		{
+			name: "conflicting device nodes",
+			edits: &cdi.ContainerEdits{
+				DeviceNodes: []*cdi.DeviceNode{
+					{Path: "/dev/foo", Type: "c", Major: 1, Minor: 2},
+					{Path: "/dev/foo", Type: "c", Major: 1, Minor: 3},
+					{Path: "/dev/bar", HostPath: "/dev/bar0"},
+					{Path: "/dev/bar", HostPath: "/dev/bar1"},
+				},
+			},
+			conflicts: []*EditConflict{
+				{
+					Kind:   DeviceNodeEdit,
+					Key:    "/dev/foo",
+					Values: []string{"/dev/foo (c 1:2)", "/dev/foo (c 1:3)"},
+				},
+				{
+					Kind:   DeviceNodeEdit,
+					Key:    "/dev/bar",
+					Values: []string{"/dev/bar0", "/dev/bar1"},
+				},
+			},
+		},
+		{
+			name: "conflicting mounts",
+			edits: &cdi.ContainerEdits{
+				Mounts: []*cdi.Mount{
+					{HostPath: "/opt/foo", ContainerPath: "/foo"},
+					{HostPath: "/opt/bar", ContainerPath: "/foo", Options: []string{"ro"}},
+				},
+			},
+			conflicts: []*EditConflict{
+				{
+					Kind:   MountEdit,
+					Key:    "/foo",
+					Values: []string{"/opt/foo", "/opt/bar options ro"},
+				},
+			},
+		},
+		{
+			name: "duplicate hooks",
+			edits: &cdi.ContainerEdits{
+				Hooks: []*cdi.Hook{
+					{HookName: PrestartHook, Path: "/bin/hook", Args: []string{"hook", "a"}},
+					{HookName: PrestartHook, Path: "/bin/hook", Args: []string{"hook", "b"}},
+					{HookName: PrestartHook, Path: "/bin/hook", Args: []string{"hook", "a"}},
+				},
+			},
+			conflicts: []*EditConflict{
+				{
+					Kind:   HookEdit,
+					Key:    "prestart /bin/hook hook a",
+					Values: []string{"/bin/hook", "/bin/hook"},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			edits := &ContainerEdits{ContainerEdits: tc.edits}
+			require.Equal(t, tc.conflicts, edits.Conflicts())
+		})
+	}
+}
+
+func TestInjectConflictPolicy(t *testing.T) {
+	const (
+		vendor1 = `
+cdiVersion: "0.3.0"
+kind:       "vendor1.com/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR1_VAR=DEV1"
+  - name: "dev2"
+    containerEdits:
+      env:
+      - "VENDOR1_VAR=DEV2"
+`
+	)
+
+	dir, err := createSpecDirs(t, map[string]string{"vendor1.yaml": vendor1}, nil)
+	require.NoError(t, err)
+
+	conflicts := []*EditConflict{
+		{
+			Kind:    EnvEdit,
+			Key:     "VENDOR1_VAR",
+			Devices: []string{"vendor1.com/device=dev1", "vendor1.com/device=dev2"},
+			Values:  []string{"DEV1", "DEV2"},
+		},
+	}
+
+	for _, policy := range []ConflictPolicy{ConflictIgnore, ConflictWarn, ConflictFail} {
+		t.Run(string(policy), func(t *testing.T) {
+			cache, err := NewCache(
+				WithSpecDirs(
+					filepath.Join(dir, "etc"),
+					filepath.Join(dir, "run"),
+				),
+				WithAutoRefresh(false),
+				WithConflictPolicy(policy),
+			)
+			require.NoError(t, err)
+			defer cache.Close()
+
+			ociSpec := &oci.Spec{}
+			report, err := cache.InjectDevicesWithReport(ociSpec,
+				"vendor1.com/device=dev1",
+				"vendor1.com/device=dev2",
+			)
+
+			switch policy {
+			case ConflictIgnore:
+				require.NoError(t, err)
+				require.Nil(t, report.Conflicts)
+				require.Equal(t, []string{"VENDOR1_VAR=DEV2"}, ociSpec.Process.Env)
+			case ConflictWarn:
+				require.NoError(t, err)
+				require.Equal(t, conflicts, report.Conflicts)
+				require.Equal(t, []string{"VENDOR1_VAR=DEV2"}, ociSpec.Process.Env)
+			case ConflictFail:
+				var conflictErr *ConflictError
+				require.True(t, errors.As(err, &conflictErr))
+				require.Equal(t, conflicts, conflictErr.Conflicts)
+				require.Equal(t, &oci.Spec{}, ociSpec)
+			}
+		})
+	}
+
+	_, err = NewCache(WithConflictPolicy("panic"))
+	require.Error(t, err)
+}
@@ -70,6 +70,20 @@ type ContainerEdits struct {
 // Apply edits to the given OCI Spec. Updates the OCI Spec in place.
 // Returns an error if the update fails.
 func (e *ContainerEdits) Apply(spec *oci.Spec) error {
+	return e.apply(spec, applyConfig{})
+}
+
+// applyConfig controls how container edits are applied.
+type applyConfig struct {
+	// rootless injects device nodes as bind mounts of the host device
+	// nodes, without any cgroup device rules.
+	rootless bool
+	// host describes how to look up host device nodes.
+	host hostDevices
+}
+
+// Apply edits to the given OCI Spec, according to the given config.
+func (e *ContainerEdits) apply(spec *oci.Spec, cfg applyConfig) error {
 	if spec == nil {
 		return errors.New("can't edit nil OCI Spec")
 	}
@@ -82,10 +96,24 @@ func (e *ContainerEdits) Apply(spec *oci.Spec) error {
 		specgen.AddMultipleProcessEnv(e.Env)
 	}
 
+	deviceMounts := false
 	for _, d := range e.DeviceNodes {
+		if cfg.rootless {
+			hostPath := deviceHostPath(d)
+			if err := cfg.host.checkDevice(hostPath); err != nil {
+				return err
+			}
+			specgen.RemoveDevice(d.Path)
+			specgen.RemoveMount(d.Path)
+			specgen.AddMount(deviceMount(d))
+			addDeviceGroup(spec, cfg.host, hostPath)
+			deviceMounts = true
+			continue
+		}
+
 		dn := DeviceNode{d}
 
-		err := dn.fillMissingInfo()
+		err := dn.fillMissingInfoFrom(cfg.host)
 		if err != nil {
 			return err
 		}
@@ -113,11 +141,11 @@ func (e *ContainerEdits) Apply(spec *oci.Spec) error {
 		}
 	}
 
-	if len(e.Mounts) > 0 {
-		for _, m := range e.Mounts {
-			specgen.RemoveMount(m.ContainerPath)
-			specgen.AddMount(m.ToOCI())
-		}
+	for _, m := range e.Mounts {
+		specgen.RemoveMount(m.ContainerPath)
+		specgen.AddMount(m.ToOCI())
+	}
+	if len(e.Mounts) > 0 || deviceMounts {
 		sortMounts(&specgen)
 	}
 
@@ -196,6 +224,85 @@ func (e *ContainerEdits) Append(o *ContainerEdits) *ContainerEdits {
 	return e
 }
 
+// FillMissingInfo fills in any missing device node information,
+// the host path, device type, and major and minor device numbers,
+// from the corresponding device on the host. It returns an error
+// if this fails for any of the device nodes. The host devices are
+// looked up directly, use Cache.FillMissingInfo() to look them up
+// according to the WithHostRoot and WithDeviceStat options.
+func (e *ContainerEdits) FillMissingInfo() error {
+	return e.fillMissingInfoFrom(hostDevices{})
+}
+
+// fillMissingInfoFrom fills in any missing device node information,
+// looking up the host devices as described by host.
+func (e *ContainerEdits) fillMissingInfoFrom(host hostDevices) error {
+	if e == nil || e.ContainerEdits == nil {
+		return nil
+	}
+	for _, d := range e.DeviceNodes {
+		if err := (&DeviceNode{d}).fillMissingInfoFrom(host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clone returns a deep copy of these edits.
+func (e *ContainerEdits) clone() *ContainerEdits {
+	c := &ContainerEdits{
+		ContainerEdits: &specs.ContainerEdits{},
+	}
+	if e == nil || e.ContainerEdits == nil {
+		return c
+	}
+
+	c.Env = cloneStrings(e.Env)
+	for _, d := range e.DeviceNodes {
+		dn := *d
+		if d.FileMode != nil {
+			mode := *d.FileMode
+			dn.FileMode = &mode
+		}
+		if d.UID != nil {
+			uid := *d.UID
+			dn.UID = &uid
+		}
+		if d.GID != nil {
+			gid := *d.GID
+			dn.GID = &gid
+		}
+		c.DeviceNodes = append(c.DeviceNodes, &dn)
+	}
+	for _, h := range e.Hooks {
+		hook := *h
+		hook.Args = cloneStrings(h.Args)
+		hook.Env = cloneStrings(h.Env)
+		if h.Timeout != nil {
+			timeout := *h.Timeout
+			hook.Timeout = &timeout
+		}
+		c.Hooks = append(c.Hooks, &hook)
+	}
+	for _, m := range e.Mounts {
+		mount := *m
+		mount.Options = cloneStrings(m.Options)
+		c.Mounts = append(c.Mounts, &mount)
+	}
+
+	return c
+}
+
+// cloneStrings returns a copy of the given string slice.
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	c := make([]string, len(s))
+	copy(c, s)
+	return c
+}
+
 // isEmpty returns true if these edits are empty. This is valid in a
 // global Spec context but invalid in a Device context.
 func (e *ContainerEdits) isEmpty() bool {
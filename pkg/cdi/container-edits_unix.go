@@ -56,8 +56,24 @@ func deviceInfoFromPath(path string) (devType string, major, minor int64, _ erro
 	return devType, int64(unix.Major(devNumber)), int64(unix.Minor(devNumber)), nil
 }
 
+// deviceOwnerFromPath takes the path to a device and returns the IDs of
+// its owning user and group.
+func deviceOwnerFromPath(path string) (uid, gid uint32, _ error) {
+	var stat unix.Stat_t
+	if err := unix.Lstat(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	return stat.Uid, stat.Gid, nil
+}
+
 // fillMissingInfo fills in missing mandatory attributes from the host device.
 func (d *DeviceNode) fillMissingInfo() error {
+	return d.fillMissingInfoFrom(hostDevices{})
+}
+
+// fillMissingInfoFrom fills in missing mandatory attributes from the host
+// device, looking up the host device as described by host.
+func (d *DeviceNode) fillMissingInfoFrom(host hostDevices) error {
 	if d.HostPath == "" {
 		d.HostPath = d.Path
 	}
@@ -66,7 +82,7 @@ func (d *DeviceNode) fillMissingInfo() error {
 		return nil
 	}
 
-	deviceType, major, minor, err := deviceInfoFromPath(d.HostPath)
+	deviceType, major, minor, err := host.deviceInfo(d.HostPath)
 	if err != nil {
 		return fmt.Errorf("failed to stat CDI host device %q: %w", d.HostPath, err)
 	}
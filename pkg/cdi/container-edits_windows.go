@@ -19,9 +19,27 @@
 
 package cdi
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 // fillMissingInfo fills in missing mandatory attributes from the host device.
 func (d *DeviceNode) fillMissingInfo() error {
+	return d.fillMissingInfoFrom(hostDevices{})
+}
+
+// fillMissingInfoFrom fills in missing mandatory attributes from the host device.
+func (d *DeviceNode) fillMissingInfoFrom(hostDevices) error {
 	return fmt.Errorf("unimplemented")
 }
+
+// deviceInfoFromPath is not supported, there are no device nodes on windows.
+func deviceInfoFromPath(string) (string, int64, int64, error) {
+	return "", 0, 0, errors.New("device nodes are not supported")
+}
+
+// deviceOwnerFromPath is not supported, there are no device nodes on windows.
+func deviceOwnerFromPath(string) (uint32, uint32, error) {
+	return 0, 0, errors.New("device nodes are not supported")
+}
@@ -0,0 +1,159 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	cdi "tags.cncf.io/container-device-interface/specs-go"
+)
+
+const (
+	// LowestVersion is the conversion target for the lowest version
+	// compatible with the content of a Spec.
+	LowestVersion = "lowest"
+	// LatestVersion is the conversion target for the latest version.
+	LatestVersion = "latest"
+)
+
+// FeatureUse is the use of a Spec feature requiring a given version.
+type FeatureUse struct {
+	// Feature is the name of the feature.
+	Feature string
+	// Version is the version which introduced the feature.
+	Version string
+	// Use describes the use of the feature in the Spec.
+	Use string
+}
+
+// String returns a human-readable description of the feature use.
+func (f FeatureUse) String() string {
+	return f.Use + " (requires " + f.Version + ")"
+}
+
+// ConversionError is the error returned when a Spec cannot be converted
+// to a target version because it uses features of later versions.
+type ConversionError struct {
+	// Version is the target version of the conversion.
+	Version string
+	// Features are the uses of features blocking the conversion.
+	Features []FeatureUse
+}
+
+// Error returns the error message for the failed conversion.
+func (e *ConversionError) Error() string {
+	var uses []string
+	for _, f := range e.Features {
+		uses = append(uses, f.String())
+	}
+	return fmt.Sprintf("cannot convert CDI Spec to version %s, it uses later features: %s",
+		e.Version, strings.Join(uses, "; "))
+}
+
+// ConvertSpec returns a copy of the Spec data with its version set to
+// the given target. The target is either an explicit version, or one of
+// LowestVersion and LatestVersion. If the Spec uses features which are
+// not supported by the target version, a *ConversionError listing these
+// features is returned.
+func ConvertSpec(raw *cdi.Spec, target string) (*cdi.Spec, error) {
+	var to version
+
+	switch target {
+	case LowestVersion:
+		to = validSpecVersions.requiredVersion(raw)
+	case LatestVersion:
+		to = vCurrent
+	default:
+		if !validSpecVersions.isValidVersion(target) {
+			return nil, fmt.Errorf("invalid version %q", target)
+		}
+		to = newVersion(target)
+		if vEarliest.IsGreaterThan(to) {
+			return nil, fmt.Errorf("unsupported version %q, the earliest supported version is %q",
+				target, vEarliest.String())
+		}
+	}
+
+	if features := blockingFeatures(raw, to); len(features) > 0 {
+		return nil, &ConversionError{
+			Version:  to.String(),
+			Features: features,
+		}
+	}
+
+	spec := *raw
+	spec.Version = to.String()
+
+	return &spec, nil
+}
+
+// ConvertSpecFile converts the given Spec file to the given target
+// version, rewriting the file in place. The encoding of the file is
+// preserved. See ConvertSpec for the possible targets. If dryRun is
+// true, the file is not rewritten. The versions the Spec file was
+// converted from and to are returned. A signed Spec file is not converted
+// if that would invalidate its signature, instead an error wrapping
+// ErrSignedSpec is returned.
+func ConvertSpecFile(path, target string, dryRun bool) (string, string, error) {
+	spec, err := ReadSpec(path, 0)
+	if err != nil {
+		return "", "", err
+	}
+
+	from := spec.Version
+	raw, err := ConvertSpec(spec.Spec, target)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := checkSignedRewrite(path, spec.Spec, raw); err != nil {
+		return "", "", err
+	}
+
+	if !dryRun {
+		spec.Spec = raw
+		if err := spec.write(true); err != nil {
+			return "", "", err
+		}
+	}
+
+	return from, raw.Version, nil
+}
+
+// blockingFeatures returns the uses of features in the Spec which are
+// not supported by the given version, sorted by version.
+func blockingFeatures(raw *cdi.Spec, to version) []FeatureUse {
+	var features []FeatureUse
+
+	for v, requiredUses := range validSpecVersions {
+		if requiredUses == nil || !v.IsGreaterThan(to) {
+			continue
+		}
+		for _, use := range requiredUses(raw) {
+			use.Version = v.String()
+			features = append(features, use)
+		}
+	}
+
+	sort.SliceStable(features, func(i, j int) bool {
+		return newVersion(features[i].Version).IsGreaterThan(newVersion(features[j].Version))
+	})
+
+	return features
+}
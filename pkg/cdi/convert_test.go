@@ -0,0 +1,219 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	cdi "tags.cncf.io/container-device-interface/specs-go"
+)
+
+func TestConvertSpec(t *testing.T) {
+	type testCase struct {
+		name     string
+		spec     *cdi.Spec
+		target   string
+		version  string
+		features []FeatureUse
+		invalid  bool
+	}
+	for _, tc := range []*testCase{
+		{
+			name: "lowest",
+			spec: &cdi.Spec{
+				Version: "0.6.0",
+				Kind:    "vendor.com/device",
+				Devices: []cdi.Device{
+					{
+						Name: "dev0",
+						ContainerEdits: cdi.ContainerEdits{
+							Env: []string{"FOO=bar"},
+						},
+					},
+				},
+			},
+			target:  LowestVersion,
+			version: "0.3.0",
+		},
+		{
+			name: "latest",
+			spec: &cdi.Spec{
+				Version: "0.3.0",
+				Kind:    "vendor.com/device",
+			},
+			target:  LatestVersion,
+			version: CurrentVersion,
+		},
+		{
+			name: "explicit upgrade",
+			spec: &cdi.Spec{
+				Version: "0.3.0",
+				Kind:    "vendor.com/device",
+			},
+			target:  "0.5.0",
+			version: "0.5.0",
+		},
+		{
+			name: "explicit downgrade",
+			spec: &cdi.Spec{
+				Version: "0.6.0",
+				Kind:    "vendor.com/device",
+				Devices: []cdi.Device{
+					{
+						Name: "dev0",
+						ContainerEdits: cdi.ContainerEdits{
+							Mounts: []*cdi.Mount{
+								{HostPath: "/foo", ContainerPath: "/foo", Type: "bind"},
+							},
+						},
+					},
+				},
+			},
+			target:  "0.4.0",
+			version: "0.4.0",
+		},
+		{
+			name: "blocked downgrade",
+			spec: &cdi.Spec{
+				Version: "0.6.0",
+				Kind:    "vendor.com/device",
+				Devices: []cdi.Device{
+					{
+						Name:        "0",
+						Annotations: map[string]string{"foo": "bar"},
+						ContainerEdits: cdi.ContainerEdits{
+							DeviceNodes: []*cdi.DeviceNode{
+								{Path: "/dev/foo", HostPath: "/dev/bar"},
+							},
+							Mounts: []*cdi.Mount{
+								{HostPath: "/foo", ContainerPath: "/foo", Type: "bind"},
+							},
+						},
+					},
+				},
+			},
+			target: "0.4.0",
+			features: []FeatureUse{
+				{
+					Feature: FeatureDeviceAnnotations,
+					Version: "0.6.0",
+					Use:     `device "0": annotations`,
+				},
+				{
+					Feature: FeatureDeviceNameNonLetterStart,
+					Version: "0.5.0",
+					Use:     `device "0": name not starting with a letter`,
+				},
+				{
+					Feature: FeatureDeviceNodeHostPath,
+					Version: "0.5.0",
+					Use:     `device "0": device node "/dev/foo": hostPath`,
+				},
+			},
+		},
+		{
+			name: "invalid version",
+			spec: &cdi.Spec{
+				Version: "0.6.0",
+				Kind:    "vendor.com/device",
+			},
+			target:  "1.0.0",
+			invalid: true,
+		},
+		{
+			name: "unsupported version",
+			spec: &cdi.Spec{
+				Version: "0.6.0",
+				Kind:    "vendor.com/device",
+			},
+			target:  "0.2.0",
+			invalid: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			orig := tc.spec.Version
+			raw, err := ConvertSpec(tc.spec, tc.target)
+			require.Equal(t, orig, tc.spec.Version, "Spec data modified")
+
+			if tc.invalid {
+				require.Error(t, err)
+				return
+			}
+			if tc.features != nil {
+				var convErr *ConversionError
+				require.True(t, errors.As(err, &convErr))
+				require.Equal(t, tc.target, convErr.Version)
+				require.Equal(t, tc.features, convErr.Features)
+				require.Nil(t, raw)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.version, raw.Version)
+		})
+	}
+}
+
+func TestConvertSpecFile(t *testing.T) {
+	const (
+		specData = `{"cdiVersion":"0.6.0","kind":"vendor.com/device","devices":[{"name":"dev0","containerEdits":{"env":["FOO=bar"]}}]}`
+	)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vendor.json")
+	require.NoError(t, os.WriteFile(path, []byte(specData), 0o644))
+
+	from, to, err := ConvertSpecFile(path, LowestVersion, true)
+	require.NoError(t, err)
+	require.Equal(t, "0.6.0", from)
+	require.Equal(t, "0.3.0", to)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, specData, string(data))
+
+	_, _, err = ConvertSpecFile(path, "0.2.0", false)
+	require.Error(t, err)
+	_, _, err = ConvertSpecFile(path, "0.5.0", false)
+	require.NoError(t, err)
+	from, to, err = ConvertSpecFile(path, LowestVersion, false)
+	require.NoError(t, err)
+	require.Equal(t, "0.5.0", from)
+	require.Equal(t, "0.3.0", to)
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, `{"cdiVersion":"0.3.0","kind":"vendor.com/device","devices":[{"name":"dev0","containerEdits":{"env":["FOO=bar"]}}],"containerEdits":{}}`,
+		string(data))
+
+	spec, err := ReadSpec(path, 0)
+	require.NoError(t, err)
+	require.Equal(t, "0.3.0", spec.Version)
+
+	// converting a signed Spec would invalidate its signature
+	require.NoError(t, os.WriteFile(SignatureFile(path), []byte("signature\n"), 0o644))
+	_, _, err = ConvertSpecFile(path, "0.6.0", true)
+	require.ErrorIs(t, err, ErrSignedSpec)
+	_, _, err = ConvertSpecFile(path, "0.6.0", false)
+	require.ErrorIs(t, err, ErrSignedSpec)
+	_, to, err = ConvertSpecFile(path, LowestVersion, false)
+	require.NoError(t, err)
+	require.Equal(t, "0.3.0", to)
+}
@@ -0,0 +1,129 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+
+	"tags.cncf.io/container-device-interface/pkg/parser"
+)
+
+const (
+	// AllDevices is the device name which, used in a device request
+	// like "vendor.com/class=all", requests all devices of the given
+	// vendor and class. If a Spec defines a device with this name, the
+	// request resolves to that device instead.
+	AllDevices = "all"
+)
+
+// IsDevicePattern returns true if the given qualified device request
+// is a pattern instead of a single device. Device patterns are either
+// the reserved name AllDevices or a glob pattern (see path.Match) for
+// the device name, for instance "vendor.com/class=gpu*". Only the name
+// of a device can be a pattern, vendor and class need to be verbatim.
+// A request for AllDevices is not expanded if a device of that name
+// exists, see ResolveDevicePatterns.
+func IsDevicePattern(device string) bool {
+	vendor, _, name := parser.ParseDevice(device)
+	if vendor == "" {
+		return false
+	}
+	return name == AllDevices || strings.ContainsAny(name, "*?[")
+}
+
+// ResolveDevicePatterns resolves the given device requests to a list of
+// qualified device names. Device patterns are expanded to all matching
+// devices in sorted order. Other requests, and requests for AllDevices
+// when a device with that name exists, are taken verbatim. Duplicate
+// devices are omitted. Any patterns which fail to match are returned as
+// unresolved.
+func (c *Cache) ResolveDevicePatterns(devices ...string) ([]string, []string) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.refreshIfRequired(context.Background(), false)
+
+	return c.expandDevices(devices)
+}
+
+// expandDevices expands any device patterns among the given requests.
+func (c *Cache) expandDevices(devices []string) ([]string, []string) {
+	var known []string
+	for name := range c.devices {
+		known = append(known, name)
+	}
+	return expandDevicePatterns(devices, known)
+}
+
+// expandDevicePatterns expands device patterns against the given devices.
+func expandDevicePatterns(requests []string, devices []string) ([]string, []string) {
+	var (
+		expanded   []string
+		unresolved []string
+		seen       = map[string]struct{}{}
+		exists     = map[string]struct{}{}
+	)
+
+	for _, device := range devices {
+		exists[device] = struct{}{}
+	}
+
+	add := func(device string) {
+		if _, ok := seen[device]; ok {
+			return
+		}
+		seen[device] = struct{}{}
+		expanded = append(expanded, device)
+	}
+
+	for _, request := range requests {
+		if _, ok := exists[request]; ok || !IsDevicePattern(request) {
+			add(request)
+			continue
+		}
+
+		var matches []string
+		vendor, class, pattern := parser.ParseDevice(request)
+		for _, device := range devices {
+			v, c, name := parser.ParseDevice(device)
+			if v != vendor || c != class {
+				continue
+			}
+			if pattern != AllDevices {
+				if ok, err := path.Match(pattern, name); err != nil || !ok {
+					continue
+				}
+			}
+			matches = append(matches, device)
+		}
+
+		if len(matches) == 0 {
+			unresolved = append(unresolved, request)
+			continue
+		}
+
+		sort.Strings(matches)
+		for _, device := range matches {
+			add(device)
+		}
+	}
+
+	return expanded, unresolved
+}
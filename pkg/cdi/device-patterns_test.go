@@ -0,0 +1,192 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"path/filepath"
+	"testing"
+
+	oci "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsDevicePattern(t *testing.T) {
+	for device, isPattern := range map[string]bool{
+		"vendor.com/class=dev0":  false,
+		"vendor.com/class=all":   true,
+		"vendor.com/class=dev*":  true,
+		"vendor.com/class=dev?":  true,
+		"vendor.com/class=[ab]":  true,
+		"vendor.com/class*=dev0": false,
+		"all":                    false,
+		"/dev/null":              false,
+	} {
+		require.Equal(t, isPattern, IsDevicePattern(device), device)
+	}
+}
+
+func TestExpandDevicePatterns(t *testing.T) {
+	devices := []string{
+		"vendor1.com/gpu=gpu10",
+		"vendor1.com/gpu=gpu1",
+		"vendor1.com/gpu=gpu0",
+		"vendor1.com/nic=nic0",
+		"vendor2.com/gpu=gpu0",
+		"vendor2.com/vgpu=0",
+		"vendor2.com/vgpu=GPU-1234",
+		"vendor2.com/vgpu=all",
+	}
+
+	type testCase struct {
+		name       string
+		requests   []string
+		expanded   []string
+		unresolved []string
+	}
+	for _, tc := range []*testCase{
+		{
+			name:     "verbatim devices",
+			requests: []string{"vendor1.com/gpu=gpu1", "vendor1.com/gpu=gpu9"},
+			expanded: []string{"vendor1.com/gpu=gpu1", "vendor1.com/gpu=gpu9"},
+		},
+		{
+			name:     "all devices of a vendor/class, sorted",
+			requests: []string{"vendor1.com/gpu=all"},
+			expanded: []string{
+				"vendor1.com/gpu=gpu0",
+				"vendor1.com/gpu=gpu1",
+				"vendor1.com/gpu=gpu10",
+			},
+		},
+		{
+			name: "globs, duplicates omitted",
+			requests: []string{
+				"vendor1.com/gpu=gpu1",
+				"vendor1.com/gpu=gpu?",
+				"vendor2.com/gpu=*",
+			},
+			expanded: []string{
+				"vendor1.com/gpu=gpu1",
+				"vendor1.com/gpu=gpu0",
+				"vendor2.com/gpu=gpu0",
+			},
+		},
+		{
+			name:     "device named all taken verbatim",
+			requests: []string{"vendor2.com/vgpu=all", "vendor2.com/vgpu=GPU-*"},
+			expanded: []string{"vendor2.com/vgpu=all", "vendor2.com/vgpu=GPU-1234"},
+		},
+		{
+			name:       "unmatched and invalid patterns",
+			requests:   []string{"vendor3.com/gpu=all", "vendor1.com/nic=[", "vendor1.com/nic=*"},
+			expanded:   []string{"vendor1.com/nic=nic0"},
+			unresolved: []string{"vendor3.com/gpu=all", "vendor1.com/nic=["},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			expanded, unresolved := expandDevicePatterns(tc.requests, devices)
+			require.Equal(t, tc.expanded, expanded)
+			require.Equal(t, tc.unresolved, unresolved)
+		})
+	}
+}
+
+func TestInjectDevicePatterns(t *testing.T) {
+	const (
+		vendor1 = `
+cdiVersion: "0.3.0"
+kind:       "vendor1.com/device"
+devices:
+  - name: "dev2"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV2=VAL2"
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV1=VAL1"
+`
+	)
+
+	dir, err := createSpecDirs(t, map[string]string{"vendor1.yaml": vendor1}, nil)
+	require.NoError(t, err)
+
+	cache, err := NewCache(
+		WithSpecDirs(
+			filepath.Join(dir, "etc"),
+			filepath.Join(dir, "run"),
+		),
+		WithAutoRefresh(false),
+	)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	ociSpec := &oci.Spec{}
+	_, err = cache.InjectDevices(ociSpec, "vendor1.com/device=all")
+	require.NoError(t, err)
+	require.Equal(t, []string{"VENDOR1_DEV1=VAL1", "VENDOR1_DEV2=VAL2"}, ociSpec.Process.Env)
+
+	unresolved, err := cache.InjectDevices(&oci.Spec{}, "vendor1.com/device=gpu*")
+	require.Error(t, err)
+	require.Equal(t, []string{"vendor1.com/device=gpu*"}, unresolved)
+}
+
+func TestInjectDeviceNamedAll(t *testing.T) {
+	const (
+		vendor1 = `
+cdiVersion: "0.5.0"
+kind:       "vendor1.com/gpu"
+devices:
+  - name: "0"
+    containerEdits:
+      env:
+      - "VENDOR1_GPU0=VAL0"
+  - name: "GPU-1234"
+    containerEdits:
+      env:
+      - "VENDOR1_GPU0=VAL0"
+  - name: "all"
+    containerEdits:
+      env:
+      - "VENDOR1_ALL=VAL"
+`
+	)
+
+	dir, err := createSpecDirs(t, map[string]string{"vendor1.yaml": vendor1}, nil)
+	require.NoError(t, err)
+
+	cache, err := NewCache(
+		WithSpecDirs(
+			filepath.Join(dir, "etc"),
+			filepath.Join(dir, "run"),
+		),
+		WithAutoRefresh(false),
+	)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	require.NotNil(t, cache.GetDevice("vendor1.com/gpu=all"))
+
+	ociSpec := &oci.Spec{}
+	_, err = cache.InjectDevices(ociSpec, "vendor1.com/gpu=all")
+	require.NoError(t, err)
+	require.Equal(t, []string{"VENDOR1_ALL=VAL"}, ociSpec.Process.Env)
+
+	devices, unresolved := cache.ResolveDevicePatterns("vendor1.com/gpu=all")
+	require.Nil(t, unresolved)
+	require.Equal(t, []string{"vendor1.com/gpu=all"}, devices)
+}
@@ -0,0 +1,280 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package diff computes semantic differences between CDI Specs.
+//
+// Devices are matched by name, so reordering devices is not a change.
+// Within container edits, environment variables are matched by variable
+// name, device nodes by container path and mounts by container path.
+// The order of these does not matter, except for mounts with the same
+// container path, which shadow each other in order. Hooks are run in
+// order, so they are compared by position within each hook name.
+//
+// Every change is reported with the path of the changed entity, for
+// instance
+//
+//	devices["gpu0"].containerEdits.env["DRIVER"]
+//	containerEdits.hooks["createContainer"][1]
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	cdi "tags.cncf.io/container-device-interface/specs-go"
+)
+
+// ChangeType is the type of a change.
+type ChangeType string
+
+const (
+	// Added is the type of changes adding an entity.
+	Added ChangeType = "added"
+	// Removed is the type of changes removing an entity.
+	Removed ChangeType = "removed"
+	// Modified is the type of changes modifying an entity.
+	Modified ChangeType = "modified"
+)
+
+// Change is a single difference between two Specs.
+type Change struct {
+	// Type of the change.
+	Type ChangeType `json:"type"`
+	// Path of the changed entity.
+	Path string `json:"path"`
+	// Old is the entity before the change, nil if it was added.
+	Old any `json:"old,omitempty"`
+	// New is the entity after the change, nil if it was removed.
+	New any `json:"new,omitempty"`
+}
+
+// String returns a human-readable description of the change.
+func (c *Change) String() string {
+	switch c.Type {
+	case Added:
+		return "+ " + c.Path + ": " + format(c.New)
+	case Removed:
+		return "- " + c.Path + ": " + format(c.Old)
+	default:
+		return "~ " + c.Path + ": " + format(c.Old) + " -> " + format(c.New)
+	}
+}
+
+// Diff is the difference between two Specs.
+type Diff struct {
+	Changes []*Change `json:"changes"`
+}
+
+// Empty checks if the Specs are semantically equal.
+func (d *Diff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// String returns a human-readable description of the changes, one per line.
+func (d *Diff) String() string {
+	var b strings.Builder
+	for _, c := range d.Changes {
+		b.WriteString(c.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Specs returns the semantic difference between the old and new Specs.
+func Specs(old, new *cdi.Spec) *Diff {
+	d := &Diff{
+		Changes: []*Change{},
+	}
+
+	d.value("cdiVersion", old.Version, new.Version)
+	d.value("kind", old.Kind, new.Kind)
+	d.annotations("annotations", old.Annotations, new.Annotations)
+	d.devices(old.Devices, new.Devices)
+	d.edits("containerEdits", &old.ContainerEdits, &new.ContainerEdits)
+
+	return d
+}
+
+// value compares two single values.
+func (d *Diff) value(path string, old, new any) {
+	if !reflect.DeepEqual(old, new) {
+		d.add(Modified, path, old, new)
+	}
+}
+
+// annotations compares two sets of annotations.
+func (d *Diff) annotations(path string, old, new map[string]string) {
+	var oldItems, newItems []item
+	for k, v := range old {
+		oldItems = append(oldItems, item{k, v})
+	}
+	for k, v := range new {
+		newItems = append(newItems, item{k, v})
+	}
+	d.keyed(path, oldItems, newItems, false)
+}
+
+// devices compares two sets of devices, matched by name.
+func (d *Diff) devices(old, new []cdi.Device) {
+	oldDevs := map[string]*cdi.Device{}
+	newDevs := map[string]*cdi.Device{}
+	for i := range old {
+		oldDevs[old[i].Name] = &old[i]
+	}
+	for i := range new {
+		newDevs[new[i].Name] = &new[i]
+	}
+
+	for _, name := range unionKeys(oldDevs, newDevs) {
+		var (
+			path   = fmt.Sprintf("devices[%q]", name)
+			o, oOk = oldDevs[name]
+			n, nOk = newDevs[name]
+		)
+		switch {
+		case !oOk:
+			d.add(Added, path, nil, n)
+		case !nOk:
+			d.add(Removed, path, o, nil)
+		default:
+			d.annotations(path+".annotations", o.Annotations, n.Annotations)
+			d.edits(path+".containerEdits", &o.ContainerEdits, &n.ContainerEdits)
+		}
+	}
+}
+
+// edits compares two sets of container edits.
+func (d *Diff) edits(path string, old, new *cdi.ContainerEdits) {
+	var oldItems, newItems []item
+
+	for _, e := range old.Env {
+		oldItems = append(oldItems, item{envName(e), e})
+	}
+	for _, e := range new.Env {
+		newItems = append(newItems, item{envName(e), e})
+	}
+	d.keyed(path+".env", oldItems, newItems, false)
+
+	oldItems, newItems = nil, nil
+	for _, n := range old.DeviceNodes {
+		oldItems = append(oldItems, item{n.Path, n})
+	}
+	for _, n := range new.DeviceNodes {
+		newItems = append(newItems, item{n.Path, n})
+	}
+	d.keyed(path+".deviceNodes", oldItems, newItems, false)
+
+	oldItems, newItems = nil, nil
+	for _, m := range old.Mounts {
+		oldItems = append(oldItems, item{m.ContainerPath, m})
+	}
+	for _, m := range new.Mounts {
+		newItems = append(newItems, item{m.ContainerPath, m})
+	}
+	d.keyed(path+".mounts", oldItems, newItems, false)
+
+	oldItems, newItems = nil, nil
+	for _, h := range old.Hooks {
+		oldItems = append(oldItems, item{h.HookName, h})
+	}
+	for _, h := range new.Hooks {
+		newItems = append(newItems, item{h.HookName, h})
+	}
+	d.keyed(path+".hooks", oldItems, newItems, true)
+}
+
+// item is a keyed entity in a collection.
+type item struct {
+	key   string
+	value any
+}
+
+// keyed compares two collections of keyed items. Items are matched by
+// key, then by their order among items with the same key. If indexed is
+// true, the position within items of the same key is always part of the
+// path, otherwise only if there are several items with the key.
+func (d *Diff) keyed(path string, old, new []item, indexed bool) {
+	oldByKey := map[string][]any{}
+	newByKey := map[string][]any{}
+	for _, i := range old {
+		oldByKey[i.key] = append(oldByKey[i.key], i.value)
+	}
+	for _, i := range new {
+		newByKey[i.key] = append(newByKey[i.key], i.value)
+	}
+
+	for _, key := range unionKeys(oldByKey, newByKey) {
+		o, n := oldByKey[key], newByKey[key]
+		multi := indexed || len(o) > 1 || len(n) > 1
+		for idx := 0; idx < len(o) || idx < len(n); idx++ {
+			p := fmt.Sprintf("%s[%q]", path, key)
+			if multi {
+				p += fmt.Sprintf("[%d]", idx)
+			}
+			switch {
+			case idx >= len(o):
+				d.add(Added, p, nil, n[idx])
+			case idx >= len(n):
+				d.add(Removed, p, o[idx], nil)
+			default:
+				d.value(p, o[idx], n[idx])
+			}
+		}
+	}
+}
+
+// add records a change.
+func (d *Diff) add(t ChangeType, path string, old, new any) {
+	d.Changes = append(d.Changes, &Change{
+		Type: t,
+		Path: path,
+		Old:  old,
+		New:  new,
+	})
+}
+
+// unionKeys returns the sorted union of the keys of two maps.
+func unionKeys[T any](a, b map[string]T) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// envName returns the name of an environment variable.
+func envName(env string) string {
+	name, _, _ := strings.Cut(env, "=")
+	return name
+}
+
+// format returns the compact JSON encoding of a value.
+func format(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
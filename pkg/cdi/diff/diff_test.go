@@ -0,0 +1,225 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package diff
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+	cdi "tags.cncf.io/container-device-interface/specs-go"
+)
+
+func TestSpecs(t *testing.T) {
+	type testCase struct {
+		name    string
+		old     string
+		new     string
+		changes []string
+	}
+	for _, tc := range []*testCase{
+		{
+			name: "reordered devices and edits",
+			old: `
+cdiVersion: "0.6.0"
+kind: vendor.com/device
+devices:
+  - name: dev0
+    containerEdits:
+      env: ["FOO=bar", "BAR=foo"]
+      deviceNodes:
+        - path: /dev/dev0
+        - path: /dev/ctl
+  - name: dev1
+    containerEdits:
+      env: ["FOO=bar"]
+`,
+			new: `
+cdiVersion: "0.6.0"
+kind: vendor.com/device
+devices:
+  - name: dev1
+    containerEdits:
+      env: ["FOO=bar"]
+  - name: dev0
+    containerEdits:
+      env: ["BAR=foo", "FOO=bar"]
+      deviceNodes:
+        - path: /dev/ctl
+        - path: /dev/dev0
+`,
+		},
+		{
+			name: "devices added and removed",
+			old: `
+cdiVersion: "0.6.0"
+kind: vendor.com/device
+devices:
+  - name: dev0
+    containerEdits:
+      env: ["FOO=bar"]
+`,
+			new: `
+cdiVersion: "0.5.0"
+kind: vendor.com/device
+devices:
+  - name: dev1
+    containerEdits:
+      env: ["FOO=bar"]
+`,
+			changes: []string{
+				`~ cdiVersion: "0.6.0" -> "0.5.0"`,
+				`- devices["dev0"]: {"name":"dev0","containerEdits":{"env":["FOO=bar"]}}`,
+				`+ devices["dev1"]: {"name":"dev1","containerEdits":{"env":["FOO=bar"]}}`,
+			},
+		},
+		{
+			name: "edits modified",
+			old: `
+cdiVersion: "0.6.0"
+kind: vendor.com/device
+annotations:
+  a: b
+containerEdits:
+  hooks:
+    - hookName: createContainer
+      path: /bin/hook1
+    - hookName: createContainer
+      path: /bin/hook2
+devices:
+  - name: dev0
+    annotations:
+      c: d
+    containerEdits:
+      env: ["FOO=bar", "BAR=foo"]
+      deviceNodes:
+        - path: /dev/dev0
+          permissions: rw
+      mounts:
+        - hostPath: /lib/a
+          containerPath: /lib/a
+        - hostPath: /lib/b
+          containerPath: /lib/b
+`,
+			new: `
+cdiVersion: "0.6.0"
+kind: vendor.com/device
+annotations:
+  a: c
+containerEdits:
+  hooks:
+    - hookName: createContainer
+      path: /bin/hook2
+    - hookName: createContainer
+      path: /bin/hook1
+devices:
+  - name: dev0
+    containerEdits:
+      env: ["FOO=baz"]
+      deviceNodes:
+        - path: /dev/dev0
+          permissions: r
+      mounts:
+        - hostPath: /lib/b
+          containerPath: /lib/b
+        - hostPath: /lib/a
+          containerPath: /lib/a
+          options: ["ro"]
+`,
+			changes: []string{
+				`~ annotations["a"]: "b" -> "c"`,
+				`- devices["dev0"].annotations["c"]: "d"`,
+				`- devices["dev0"].containerEdits.env["BAR"]: "BAR=foo"`,
+				`~ devices["dev0"].containerEdits.env["FOO"]: "FOO=bar" -> "FOO=baz"`,
+				`~ devices["dev0"].containerEdits.deviceNodes["/dev/dev0"]: {"path":"/dev/dev0","permissions":"rw"} -> {"path":"/dev/dev0","permissions":"r"}`,
+				`~ devices["dev0"].containerEdits.mounts["/lib/a"]: {"hostPath":"/lib/a","containerPath":"/lib/a"} -> {"hostPath":"/lib/a","containerPath":"/lib/a","options":["ro"]}`,
+				`~ containerEdits.hooks["createContainer"][0]: {"hookName":"createContainer","path":"/bin/hook1"} -> {"hookName":"createContainer","path":"/bin/hook2"}`,
+				`~ containerEdits.hooks["createContainer"][1]: {"hookName":"createContainer","path":"/bin/hook2"} -> {"hookName":"createContainer","path":"/bin/hook1"}`,
+			},
+		},
+		{
+			name: "shadowing mounts reordered",
+			old: `
+cdiVersion: "0.6.0"
+kind: vendor.com/device
+devices:
+  - name: dev0
+    containerEdits:
+      mounts:
+        - hostPath: /lib/a
+          containerPath: /lib
+        - hostPath: /lib/b
+          containerPath: /lib
+`,
+			new: `
+cdiVersion: "0.6.0"
+kind: vendor.com/device
+devices:
+  - name: dev0
+    containerEdits:
+      mounts:
+        - hostPath: /lib/b
+          containerPath: /lib
+        - hostPath: /lib/a
+          containerPath: /lib
+        - hostPath: /lib/c
+          containerPath: /lib
+`,
+			changes: []string{
+				`~ devices["dev0"].containerEdits.mounts["/lib"][0]: {"hostPath":"/lib/a","containerPath":"/lib"} -> {"hostPath":"/lib/b","containerPath":"/lib"}`,
+				`~ devices["dev0"].containerEdits.mounts["/lib"][1]: {"hostPath":"/lib/b","containerPath":"/lib"} -> {"hostPath":"/lib/a","containerPath":"/lib"}`,
+				`+ devices["dev0"].containerEdits.mounts["/lib"][2]: {"hostPath":"/lib/c","containerPath":"/lib"}`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var old, new *cdi.Spec
+			require.NoError(t, yaml.Unmarshal([]byte(tc.old), &old))
+			require.NoError(t, yaml.Unmarshal([]byte(tc.new), &new))
+
+			d := Specs(old, new)
+			require.Equal(t, len(tc.changes) == 0, d.Empty())
+
+			var changes []string
+			for _, c := range d.Changes {
+				changes = append(changes, c.String())
+			}
+			require.Equal(t, tc.changes, changes)
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	old := &cdi.Spec{
+		Version: "0.6.0",
+		Kind:    "vendor.com/device",
+	}
+	new := &cdi.Spec{
+		Version:     "0.6.0",
+		Kind:        "vendor.com/device",
+		Annotations: map[string]string{"a": "b"},
+	}
+
+	data, err := json.Marshal(Specs(old, new))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"changes":[{"type":"added","path":"annotations[\"a\"]","new":"b"}]}`, string(data))
+
+	data, err = json.Marshal(Specs(old, old))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"changes":[]}`, string(data))
+}
@@ -254,6 +254,13 @@
 //	    return registry.SpecDB().RemoveSpec(specName)
 //	}
 //
+// If the entity generating transient Specs crashes before removing them,
+// the stale Spec files are left behind. A TransientSpecManager can be used
+// instead to write transient Specs annotated with their owner, creation
+// time and an optional time to live. Its GC() function removes transient
+// Specs which have expired or whose owner is gone, as reported by a given
+// callback. The 'cdi gc' command does the same from the command line.
+//
 // # CDI Spec Validation
 //
 // This package performs both syntactic and semantic validation of CDI
@@ -271,6 +278,10 @@
 // the special "builtin" (BuiltinSchemaName) and "none" (NoneSchemaName)
 // schema names which switch the used schema to the in-repo validation
 // schema embedded into the binary or the now default no-op schema
-// correspondingly. Other names are interpreted as the path to the actual
-// validation schema to load and use.
+// correspondingly. The builtin schema validates each Spec against the
+// schema of its cdiVersion, which also enforces the rules of that version
+// for kind and device names. A "builtin:" (BuiltinVersionPrefix) prefixed
+// version, for instance "builtin:v0.5.0", selects the schema of a single
+// version for all Specs. Other names are interpreted as the path to the
+// actual validation schema to load and use.
 package cdi
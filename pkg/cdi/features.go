@@ -0,0 +1,117 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Names of Spec features introduced after the earliest supported version.
+const (
+	// FeatureMountType is the type field of mounts.
+	FeatureMountType = "mount-type"
+	// FeatureDeviceNodeHostPath is the hostPath field of device nodes.
+	FeatureDeviceNodeHostPath = "device-node-host-path"
+	// FeatureDeviceNameNonLetterStart is device names not starting with a letter.
+	FeatureDeviceNameNonLetterStart = "device-name-non-letter-start"
+	// FeatureSpecAnnotations is annotations of Specs.
+	FeatureSpecAnnotations = "spec-annotations"
+	// FeatureDeviceAnnotations is annotations of devices.
+	FeatureDeviceAnnotations = "device-annotations"
+	// FeatureClassNameDots is dots in class names.
+	FeatureClassNameDots = "class-name-dots"
+)
+
+// Feature is a Spec feature introduced in a given version.
+type Feature struct {
+	// Name of the feature.
+	Name string
+	// Version which introduced the feature.
+	Version string
+	// Description of the feature.
+	Description string
+}
+
+// SupportedBy checks if the feature is supported by the given version.
+func (f Feature) SupportedBy(v string) bool {
+	return !newVersion(f.Version).IsGreaterThan(newVersion(v))
+}
+
+// features is the table of Spec features, sorted by version. The uses
+// of features detected by validSpecVersions and the versions of fields
+// in the generated schema must agree with it, which is tested.
+var features = []Feature{
+	{FeatureMountType, v040.String(), "type field of mounts"},
+	{FeatureDeviceNodeHostPath, v050.String(), "hostPath field of device nodes"},
+	{FeatureDeviceNameNonLetterStart, v050.String(), "device names not starting with a letter"},
+	{FeatureSpecAnnotations, v060.String(), "annotations of Specs"},
+	{FeatureDeviceAnnotations, v060.String(), "annotations of devices"},
+	{FeatureClassNameDots, v060.String(), "dots in class names"},
+}
+
+// Features returns the table of Spec features introduced after the
+// earliest supported version, sorted by the version which introduced
+// them. Producers of Specs can use it to decide which features to use,
+// given the latest version understood by the consumers of their Specs.
+func Features() []Feature {
+	return append([]Feature{}, features...)
+}
+
+// GetFeature returns the Spec feature with the given name, or nil if
+// there is no such feature.
+func GetFeature(name string) *Feature {
+	for _, f := range features {
+		if f.Name == name {
+			return &f
+		}
+	}
+	return nil
+}
+
+// FeaturesSupportedBy returns the Spec features supported by the given
+// version.
+func FeaturesSupportedBy(v string) ([]Feature, error) {
+	if !validSpecVersions.isValidVersion(v) {
+		return nil, fmt.Errorf("invalid version %q", v)
+	}
+
+	var supported []Feature
+	for _, f := range features {
+		if f.SupportedBy(v) {
+			supported = append(supported, f)
+		}
+	}
+	return supported, nil
+}
+
+// SpecVersions returns all valid Spec versions, sorted in ascending order.
+func SpecVersions() []string {
+	var versions []version
+	for v := range validSpecVersions {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[j].IsGreaterThan(versions[i])
+	})
+
+	var result []string
+	for _, v := range versions {
+		result = append(result, v.String())
+	}
+	return result
+}
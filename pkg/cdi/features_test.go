@@ -0,0 +1,155 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	cdi "tags.cncf.io/container-device-interface/specs-go"
+)
+
+func TestFeatures(t *testing.T) {
+	require.Equal(t, []string{"0.1.0", "0.2.0", "0.3.0", "0.4.0", "0.5.0", "0.6.0"}, SpecVersions())
+
+	// a Spec using all features, each use must match the feature table
+	raw := &cdi.Spec{
+		Version:     "0.6.0",
+		Kind:        "vendor.com/device.class",
+		Annotations: map[string]string{"foo": "bar"},
+		Devices: []cdi.Device{
+			{
+				Name:        "0",
+				Annotations: map[string]string{"foo": "bar"},
+				ContainerEdits: cdi.ContainerEdits{
+					DeviceNodes: []*cdi.DeviceNode{
+						{Path: "/dev/foo", HostPath: "/dev/bar"},
+					},
+					Mounts: []*cdi.Mount{
+						{HostPath: "/foo", ContainerPath: "/foo", Type: "bind"},
+					},
+				},
+			},
+		},
+	}
+
+	used := map[string]bool{}
+	for _, use := range blockingFeatures(raw, vEarliest) {
+		f := GetFeature(use.Feature)
+		require.NotNil(t, f, "feature %q missing from table", use.Feature)
+		require.Equal(t, f.Version, use.Version, "feature %q", use.Feature)
+		used[use.Feature] = true
+	}
+	for _, f := range Features() {
+		require.True(t, used[f.Name], "feature %q not detected", f.Name)
+	}
+
+	require.Nil(t, GetFeature("no-such-feature"))
+
+	supported, err := FeaturesSupportedBy("0.5.0")
+	require.NoError(t, err)
+	var names []string
+	for _, f := range supported {
+		names = append(names, f.Name)
+	}
+	require.Equal(t, []string{
+		FeatureMountType,
+		FeatureDeviceNodeHostPath,
+		FeatureDeviceNameNonLetterStart,
+	}, names)
+
+	supported, err = FeaturesSupportedBy("0.3.0")
+	require.NoError(t, err)
+	require.Empty(t, supported)
+
+	_, err = FeaturesSupportedBy("0.7.0")
+	require.Error(t, err)
+}
+
+func TestMaxSpecVersion(t *testing.T) {
+	const (
+		v030 = `
+cdiVersion: "0.3.0"
+kind: "vendor.com/device"
+devices:
+  - name: "dev0"
+    containerEdits:
+      env:
+        - "FOO=bar"
+`
+		v060 = `
+cdiVersion: "0.6.0"
+kind: "vendor.com/other"
+annotations:
+  foo: bar
+devices:
+  - name: "dev0"
+    containerEdits:
+      env:
+        - "FOO=bar"
+`
+		// a future version, with fields unknown to this version
+		v999 = `
+cdiVersion: "9.9.9"
+kind: "vendor.com/future"
+devices:
+  - name: "dev0"
+    futureField: true
+    containerEdits:
+      env:
+        - "FOO=bar"
+`
+	)
+
+	dir, err := createSpecDirs(t, map[string]string{
+		"v030.yaml": v030,
+		"v060.yaml": v060,
+		"v999.yaml": v999,
+	}, nil)
+	require.NoError(t, err)
+	etc := filepath.Join(dir, "etc")
+
+	_, err = NewCache(WithMaxSpecVersion("0.7.0"))
+	require.Error(t, err)
+
+	cache, err := NewCache(
+		WithSpecDirs(etc),
+		WithAutoRefresh(false),
+		WithMaxSpecVersion("0.5.0"),
+	)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	require.Equal(t, []string{"vendor.com/device=dev0"}, cache.ListDevices())
+
+	errs := cache.GetErrors()
+	require.Len(t, errs, 2)
+	for path, pathErrs := range errs {
+		require.Len(t, pathErrs, 1)
+		var versionErr *SpecVersionError
+		require.True(t, errors.As(pathErrs[0], &versionErr), "unexpected error %v", pathErrs[0])
+		require.Equal(t, path, versionErr.Path)
+		require.Equal(t, "0.5.0", versionErr.MaxVersion)
+	}
+	require.Contains(t, errs, filepath.Join(etc, "v060.yaml"))
+	require.Contains(t, errs, filepath.Join(etc, "v999.yaml"))
+
+	require.NoError(t, cache.Configure(WithMaxSpecVersion("")))
+	require.Equal(t, []string{"vendor.com/device=dev0", "vendor.com/other=dev0"}, cache.ListDevices())
+}
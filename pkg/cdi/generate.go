@@ -0,0 +1,157 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	cdi "tags.cncf.io/container-device-interface/specs-go"
+)
+
+// GenerateConfig describes a CDI Spec to generate from host device nodes.
+type GenerateConfig struct {
+	// Vendor and Class set the kind of the generated Spec.
+	Vendor string
+	Class  string
+	// DeviceGlobs are glob patterns for host device nodes, for instance
+	// "/dev/kvm" or "/dev/hidraw*". Each matching device node becomes a
+	// device named after the base name of the device node.
+	DeviceGlobs []string
+	// Env is added to the container edits of each device.
+	Env []string
+	// Mounts are added to the container edits of each device.
+	Mounts []*cdi.Mount
+	// HostRoot is the directory the host filesystem is available under.
+	// Device globs are matched under this directory, while the paths in
+	// the generated Spec remain relative to the real root of the host.
+	// See WithHostRoot.
+	HostRoot string
+	// DeviceStat is used to look up host device nodes. See WithDeviceStat.
+	DeviceStat DeviceStatFunc
+}
+
+// GenerateSpec generates CDI Spec data for the host device nodes matching
+// the device globs of the given configuration. The type, major and minor
+// numbers of device nodes are looked up from the host. The version of the
+// generated Spec is the minimum version required for its content. The
+// result can be written to a Spec directory using WriteSpec.
+//
+// It is an error if a glob does not match any device node, if a match is
+// not a device node, or if two device nodes map to the same device name.
+func GenerateSpec(cfg *GenerateConfig) (*cdi.Spec, error) {
+	if cfg.HostRoot != "" && !filepath.IsAbs(cfg.HostRoot) {
+		return nil, fmt.Errorf("invalid host root %q, not an absolute path", cfg.HostRoot)
+	}
+	if len(cfg.DeviceGlobs) == 0 {
+		return nil, fmt.Errorf("no device globs given")
+	}
+
+	var (
+		host  = hostDevices{root: cfg.HostRoot, stat: cfg.DeviceStat}
+		paths = map[string]string{}
+		names []string
+	)
+
+	for _, glob := range cfg.DeviceGlobs {
+		if !filepath.IsAbs(glob) {
+			return nil, fmt.Errorf("invalid device glob %q, not an absolute path", glob)
+		}
+		matches, err := filepath.Glob(host.path(glob))
+		if err != nil {
+			return nil, fmt.Errorf("invalid device glob %q: %w", glob, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no device nodes found for %q", glob)
+		}
+		for _, match := range matches {
+			path, err := hostRelativePath(cfg.HostRoot, match)
+			if err != nil {
+				return nil, err
+			}
+			name := filepath.Base(path)
+			if other, ok := paths[name]; ok {
+				if other == path {
+					continue
+				}
+				return nil, fmt.Errorf("device nodes %q and %q map to the same device name %q",
+					other, path, name)
+			}
+			paths[name] = path
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	raw := &cdi.Spec{
+		Kind: cfg.Vendor + "/" + cfg.Class,
+	}
+
+	common := &ContainerEdits{
+		ContainerEdits: &cdi.ContainerEdits{
+			Env:    cfg.Env,
+			Mounts: cfg.Mounts,
+		},
+	}
+
+	for _, name := range names {
+		path := paths[name]
+		devType, major, minor, err := host.deviceInfo(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat CDI host device %q: %w", path, err)
+		}
+		edits := common.clone()
+		edits.DeviceNodes = []*cdi.DeviceNode{
+			{
+				Path:  path,
+				Type:  devType,
+				Major: major,
+				Minor: minor,
+			},
+		}
+		raw.Devices = append(raw.Devices, cdi.Device{
+			Name:           name,
+			ContainerEdits: *edits.ContainerEdits,
+		})
+	}
+
+	version, err := MinimumRequiredVersion(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get required CDI Spec version: %w", err)
+	}
+	raw.Version = version
+
+	if _, err := newSpec(raw, "", 0); err != nil {
+		return nil, fmt.Errorf("failed to generate CDI Spec: %w", err)
+	}
+
+	return raw, nil
+}
+
+// hostRelativePath returns the given path relative to the host root.
+func hostRelativePath(root, path string) (string, error) {
+	if root == "" {
+		return path, nil
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to strip host root %q from %q: %w", root, path, err)
+	}
+	return string(filepath.Separator) + rel, nil
+}
@@ -0,0 +1,197 @@
+//go:build !windows
+// +build !windows
+
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+	cdi "tags.cncf.io/container-device-interface/specs-go"
+)
+
+func TestGenerateSpec(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"dev/vfio", "dev/dri"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0o755))
+	}
+	for _, fifo := range []string{"dev/hidraw0", "dev/hidraw1", "dev/vfio/1", "dev/dri/card0", "dev/card0"} {
+		require.NoError(t, unix.Mkfifo(filepath.Join(root, fifo), 0o600))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, "dev/file"), nil, 0o600))
+
+	type testCase struct {
+		name   string
+		cfg    *GenerateConfig
+		result *cdi.Spec
+		errMsg string
+	}
+	for _, tc := range []*testCase{
+		{
+			name: "globs",
+			cfg: &GenerateConfig{
+				Vendor:      "vendor.com",
+				Class:       "device",
+				DeviceGlobs: []string{"/dev/hidraw*", "/dev/vfio/*", "/dev/hidraw0"},
+				Env:         []string{"FOO=bar"},
+				Mounts: []*cdi.Mount{
+					{HostPath: "/usr/lib/vendor", ContainerPath: "/usr/lib/vendor"},
+				},
+				HostRoot: root,
+			},
+			result: &cdi.Spec{
+				// device names starting with a digit require v0.5.0
+				Version: "0.5.0",
+				Kind:    "vendor.com/device",
+				Devices: []cdi.Device{
+					{
+						Name: "1",
+						ContainerEdits: cdi.ContainerEdits{
+							Env:         []string{"FOO=bar"},
+							DeviceNodes: []*cdi.DeviceNode{{Path: "/dev/vfio/1", Type: "p"}},
+							Mounts: []*cdi.Mount{
+								{HostPath: "/usr/lib/vendor", ContainerPath: "/usr/lib/vendor"},
+							},
+						},
+					},
+					{
+						Name: "hidraw0",
+						ContainerEdits: cdi.ContainerEdits{
+							Env:         []string{"FOO=bar"},
+							DeviceNodes: []*cdi.DeviceNode{{Path: "/dev/hidraw0", Type: "p"}},
+							Mounts: []*cdi.Mount{
+								{HostPath: "/usr/lib/vendor", ContainerPath: "/usr/lib/vendor"},
+							},
+						},
+					},
+					{
+						Name: "hidraw1",
+						ContainerEdits: cdi.ContainerEdits{
+							Env:         []string{"FOO=bar"},
+							DeviceNodes: []*cdi.DeviceNode{{Path: "/dev/hidraw1", Type: "p"}},
+							Mounts: []*cdi.Mount{
+								{HostPath: "/usr/lib/vendor", ContainerPath: "/usr/lib/vendor"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "device stat",
+			cfg: &GenerateConfig{
+				Vendor:      "vendor.com",
+				Class:       "device",
+				DeviceGlobs: []string{"/dev/hidraw0"},
+				HostRoot:    root,
+				DeviceStat: func(path string) (string, int64, int64, error) {
+					require.Equal(t, filepath.Join(root, "dev/hidraw0"), path)
+					return "c", 242, 0, nil
+				},
+			},
+			result: &cdi.Spec{
+				Version: "0.3.0",
+				Kind:    "vendor.com/device",
+				Devices: []cdi.Device{
+					{
+						Name: "hidraw0",
+						ContainerEdits: cdi.ContainerEdits{
+							DeviceNodes: []*cdi.DeviceNode{
+								{Path: "/dev/hidraw0", Type: "c", Major: 242},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "no match",
+			cfg: &GenerateConfig{
+				Vendor:      "vendor.com",
+				Class:       "device",
+				DeviceGlobs: []string{"/dev/kvm"},
+				HostRoot:    root,
+			},
+			errMsg: `no device nodes found for "/dev/kvm"`,
+		},
+		{
+			name: "not a device node",
+			cfg: &GenerateConfig{
+				Vendor:      "vendor.com",
+				Class:       "device",
+				DeviceGlobs: []string{"/dev/file"},
+				HostRoot:    root,
+			},
+			errMsg: "not a device node",
+		},
+		{
+			name: "duplicate device name",
+			cfg: &GenerateConfig{
+				Vendor:      "vendor.com",
+				Class:       "device",
+				DeviceGlobs: []string{"/dev/card0", "/dev/dri/*"},
+				HostRoot:    root,
+			},
+			errMsg: `map to the same device name "card0"`,
+		},
+		{
+			name: "invalid kind",
+			cfg: &GenerateConfig{
+				Vendor:      "vendor.com",
+				Class:       "_device",
+				DeviceGlobs: []string{"/dev/hidraw0"},
+				HostRoot:    root,
+			},
+			errMsg: "failed to generate CDI Spec",
+		},
+		{
+			name: "relative glob",
+			cfg: &GenerateConfig{
+				Vendor:      "vendor.com",
+				Class:       "device",
+				DeviceGlobs: []string{"dev/hidraw0"},
+				HostRoot:    root,
+			},
+			errMsg: "not an absolute path",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := GenerateSpec(tc.cfg)
+			if tc.errMsg != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.result, raw)
+
+			cache, err := NewCache(
+				WithSpecDirs(t.TempDir()),
+				WithAutoRefresh(false),
+			)
+			require.NoError(t, err)
+			defer cache.Close()
+			require.NoError(t, cache.WriteSpec(raw, "vendor.com-device"))
+			require.NoError(t, cache.Refresh())
+		})
+	}
+}
@@ -0,0 +1,102 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// DeviceStatFunc looks up the host device node at the given path. It
+// returns the type of the device node, "b", "c" or "p", and its major
+// and minor device numbers.
+type DeviceStatFunc func(path string) (devType string, major, minor int64, err error)
+
+// WithHostRoot returns an option to set the directory the filesystem of
+// the host is available under. Host device nodes are then looked up under
+// this directory when filling in missing device node information. The
+// paths in the OCI Spec are not affected, these remain relative to the
+// real root of the host. This is useful if the Cache is used within a
+// container with the host filesystem mounted, for instance, at /host.
+// An empty directory, the default, is the same as "/".
+func WithHostRoot(root string) Option {
+	return func(c *Cache) error {
+		if root != "" && !filepath.IsAbs(root) {
+			return fmt.Errorf("invalid host root %q, not an absolute path", root)
+		}
+		c.host.root = root
+		return nil
+	}
+}
+
+// WithDeviceStat returns an option to set the function used to look up
+// host device nodes. This is mostly useful for testing without the real
+// device nodes present. Passing nil restores the default, which uses
+// lstat(2) on the device node.
+func WithDeviceStat(stat DeviceStatFunc) Option {
+	return func(c *Cache) error {
+		c.host.stat = stat
+		return nil
+	}
+}
+
+// hostDevices describes how host device nodes are looked up.
+type hostDevices struct {
+	root  string
+	stat  DeviceStatFunc
+	owner func(path string) (uid, gid uint32, err error)
+}
+
+// path returns the path to look up the given host path at.
+func (h hostDevices) path(hostPath string) string {
+	if h.root == "" {
+		return hostPath
+	}
+	return filepath.Join(h.root, hostPath)
+}
+
+// deviceInfo looks up the host device node at the given host path.
+func (h hostDevices) deviceInfo(hostPath string) (string, int64, int64, error) {
+	stat := h.stat
+	if stat == nil {
+		stat = deviceInfoFromPath
+	}
+	return stat(h.path(hostPath))
+}
+
+// checkDevice checks that the given host path is a block or character
+// device node.
+func (h hostDevices) checkDevice(hostPath string) error {
+	devType, _, _, err := h.deviceInfo(hostPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat CDI host device %q: %w", hostPath, err)
+	}
+	if devType != "b" && devType != "c" {
+		return fmt.Errorf("CDI host device %q is not a block or character device", hostPath)
+	}
+	return nil
+}
+
+// deviceOwner looks up the owner of the host device node at the given
+// host path.
+func (h hostDevices) deviceOwner(hostPath string) (uint32, uint32, error) {
+	owner := h.owner
+	if owner == nil {
+		owner = deviceOwnerFromPath
+	}
+	return owner(h.path(hostPath))
+}
@@ -0,0 +1,114 @@
+//go:build !windows
+// +build !windows
+
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	oci "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestHostRoot(t *testing.T) {
+	const (
+		vendor1 = `
+cdiVersion: "0.5.0"
+kind:       "vendor1.com/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      deviceNodes:
+      - path: "/dev/vendor1-dev1"
+        hostPath: "/dev/vendor1/dev1"
+  - name: "fifo"
+    containerEdits:
+      deviceNodes:
+      - path: "/dev/vendor1-fifo"
+`
+	)
+
+	dir, err := createSpecDirs(t, map[string]string{"vendor1.yaml": vendor1}, nil)
+	require.NoError(t, err)
+
+	var stats []string
+	fakeStat := func(path string) (string, int64, int64, error) {
+		stats = append(stats, path)
+		if path != "/host/dev/vendor1/dev1" {
+			return "", 0, 0, fmt.Errorf("%s: %w", path, os.ErrNotExist)
+		}
+		return "c", 10, 1, nil
+	}
+
+	cache, err := NewCache(
+		WithSpecDirs(
+			filepath.Join(dir, "etc"),
+			filepath.Join(dir, "run"),
+		),
+		WithAutoRefresh(false),
+		WithHostRoot("/host"),
+		WithDeviceStat(fakeStat),
+	)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	ociSpec := &oci.Spec{}
+	_, err = cache.InjectDevices(ociSpec, "vendor1.com/device=dev1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"/host/dev/vendor1/dev1"}, stats)
+	require.Equal(t,
+		[]oci.LinuxDevice{
+			{Path: "/dev/vendor1-dev1", Type: "c", Major: 10, Minor: 1},
+		},
+		ociSpec.Linux.Devices,
+	)
+
+	_, err = cache.InjectDevices(&oci.Spec{}, "vendor1.com/device=fifo")
+	require.Error(t, err)
+
+	// look up real host device nodes under the host root
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "dev"), 0755))
+	require.NoError(t, unix.Mkfifo(filepath.Join(root, "dev", "vendor1-fifo"), 0600))
+	require.NoError(t, cache.Configure(WithHostRoot(root), WithDeviceStat(nil)))
+
+	ociSpec = &oci.Spec{}
+	_, err = cache.InjectDevices(ociSpec, "vendor1.com/device=fifo")
+	require.NoError(t, err)
+	require.Equal(t,
+		[]oci.LinuxDevice{
+			{Path: "/dev/vendor1-fifo", Type: "p"},
+		},
+		ociSpec.Linux.Devices,
+	)
+
+	// resolved edits are filled in from the same host root
+	edits, _, err := cache.ResolveEdits("vendor1.com/device=fifo")
+	require.NoError(t, err)
+	require.Error(t, edits.FillMissingInfo())
+	require.NoError(t, cache.FillMissingInfo(edits))
+	require.Equal(t, "p", edits.DeviceNodes[0].Type)
+	require.Equal(t, "/dev/vendor1-fifo", edits.DeviceNodes[0].HostPath)
+
+	require.Error(t, cache.Configure(WithHostRoot("host")))
+}
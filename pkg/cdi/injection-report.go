@@ -0,0 +1,256 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	oci "github.com/opencontainers/runtime-spec/specs-go"
+	"tags.cncf.io/container-device-interface/specs-go"
+)
+
+// EditScope tells whether container edits are global to a Spec or
+// specific to a single device.
+type EditScope string
+
+const (
+	// SpecScope is the scope of global Spec container edits.
+	SpecScope EditScope = "spec"
+	// DeviceScope is the scope of device-specific container edits.
+	DeviceScope EditScope = "device"
+)
+
+// EditKind is the type of a single container edit.
+type EditKind string
+
+const (
+	// EnvEdit is an environment variable edit.
+	EnvEdit EditKind = "env"
+	// DeviceNodeEdit is a device node edit.
+	DeviceNodeEdit EditKind = "deviceNode"
+	// HookEdit is an OCI hook edit.
+	HookEdit EditKind = "hook"
+	// MountEdit is a mount edit.
+	MountEdit EditKind = "mount"
+)
+
+// InjectionReport describes the outcome of injecting CDI devices into
+// an OCI Spec. It is returned by InjectDevicesWithReport() and can be
+// serialized to JSON.
+type InjectionReport struct {
+	// Devices are the qualified names of the injected devices.
+	Devices []string `json:"devices,omitempty"`
+	// Unresolved are the qualified names of unresolvable devices.
+	Unresolved []string `json:"unresolved,omitempty"`
+	// Edits are the applied edits, in the order of application.
+	Edits []*AppliedEdit `json:"edits,omitempty"`
+	// Replaced are the pre-existing OCI Spec entries replaced by edits.
+	Replaced []*ReplacedEntry `json:"replaced,omitempty"`
+	// Conflicts are any detected conflicts among the applied edits.
+	Conflicts []*EditConflict `json:"conflicts,omitempty"`
+	// Violations are any violations of the admission policy.
+	Violations []*PolicyViolation `json:"violations,omitempty"`
+}
+
+// AppliedEdit describes a single applied container edit and its origin.
+// Exactly one of Env, DeviceNode, Hook, or Mount is set, according to
+// Kind. For global Spec edits Device is the first injected device which
+// caused the edits of the Spec to get applied.
+type AppliedEdit struct {
+	Kind       EditKind          `json:"kind"`
+	Scope      EditScope         `json:"scope"`
+	Device     string            `json:"device"`
+	Spec       string            `json:"spec"`
+	Env        string            `json:"env,omitempty"`
+	DeviceNode *specs.DeviceNode `json:"deviceNode,omitempty"`
+	Hook       *specs.Hook       `json:"hook,omitempty"`
+	Mount      *specs.Mount      `json:"mount,omitempty"`
+}
+
+// ReplacedEntry describes an entry of the OCI Spec which was present
+// before injection and got replaced by an injected edit. Device and Spec
+// identify the origin of the replacing edit. Exactly one of Env,
+// DeviceNode, or Mount is set, according to Kind.
+type ReplacedEntry struct {
+	Kind       EditKind         `json:"kind"`
+	Device     string           `json:"device"`
+	Spec       string           `json:"spec"`
+	Env        string           `json:"env,omitempty"`
+	DeviceNode *oci.LinuxDevice `json:"deviceNode,omitempty"`
+	Mount      *oci.Mount       `json:"mount,omitempty"`
+}
+
+// editSet is a set of container edits together with their origin.
+type editSet struct {
+	scope  EditScope
+	device *Device
+	edits  *ContainerEdits
+}
+
+// mergeEdits merges the given sets of edits into a single set of edits.
+func mergeEdits(sets []*editSet) *ContainerEdits {
+	edits := &ContainerEdits{
+		ContainerEdits: &specs.ContainerEdits{},
+	}
+	for _, s := range sets {
+		edits.Append(s.edits)
+	}
+	return edits
+}
+
+// record the given sets of edits, and any OCI Spec entries they replace,
+// in the report. This needs to be called before the edits get applied.
+// In rootless mode device nodes are injected as bind mounts, replacing
+// any mounts at their path.
+func (r *InjectionReport) record(ociSpec *oci.Spec, sets []*editSet, rootless bool) {
+	var (
+		seen     = map[string]struct{}{}
+		env      = map[string]struct{}{}
+		devNodes = map[string]struct{}{}
+		mounts   = map[string]struct{}{}
+	)
+
+	for _, s := range sets {
+		var (
+			device = s.device.GetQualifiedName()
+			spec   = s.device.GetSpec().GetPath()
+			edits  = s.edits
+		)
+
+		if _, ok := seen[device]; !ok {
+			seen[device] = struct{}{}
+			r.Devices = append(r.Devices, device)
+		}
+
+		newEdit := func(kind EditKind) *AppliedEdit {
+			return &AppliedEdit{
+				Kind:   kind,
+				Scope:  s.scope,
+				Device: device,
+				Spec:   spec,
+			}
+		}
+		replaced := func(kind EditKind) *ReplacedEntry {
+			return &ReplacedEntry{
+				Kind:   kind,
+				Device: device,
+				Spec:   spec,
+			}
+		}
+
+		for _, e := range edits.Env {
+			edit := newEdit(EnvEdit)
+			edit.Env = e
+			r.Edits = append(r.Edits, edit)
+
+			key := envKey(e)
+			if _, ok := env[key]; ok {
+				continue
+			}
+			env[key] = struct{}{}
+			if old, ok := lookupOCIEnv(ociSpec, key); ok {
+				entry := replaced(EnvEdit)
+				entry.Env = old
+				r.Replaced = append(r.Replaced, entry)
+			}
+		}
+		for _, d := range edits.DeviceNodes {
+			edit := newEdit(DeviceNodeEdit)
+			edit.DeviceNode = d
+			r.Edits = append(r.Edits, edit)
+
+			if _, ok := devNodes[d.Path]; ok {
+				continue
+			}
+			devNodes[d.Path] = struct{}{}
+			if old, ok := lookupOCIDevice(ociSpec, d.Path); ok {
+				entry := replaced(DeviceNodeEdit)
+				entry.DeviceNode = old
+				r.Replaced = append(r.Replaced, entry)
+			}
+
+			if !rootless {
+				continue
+			}
+			if _, ok := mounts[d.Path]; ok {
+				continue
+			}
+			mounts[d.Path] = struct{}{}
+			if old, ok := lookupOCIMount(ociSpec, d.Path); ok {
+				entry := replaced(MountEdit)
+				entry.Mount = old
+				r.Replaced = append(r.Replaced, entry)
+			}
+		}
+		for _, h := range edits.Hooks {
+			edit := newEdit(HookEdit)
+			edit.Hook = h
+			r.Edits = append(r.Edits, edit)
+		}
+		for _, m := range edits.Mounts {
+			edit := newEdit(MountEdit)
+			edit.Mount = m
+			r.Edits = append(r.Edits, edit)
+
+			if _, ok := mounts[m.ContainerPath]; ok {
+				continue
+			}
+			mounts[m.ContainerPath] = struct{}{}
+			if old, ok := lookupOCIMount(ociSpec, m.ContainerPath); ok {
+				entry := replaced(MountEdit)
+				entry.Mount = old
+				r.Replaced = append(r.Replaced, entry)
+			}
+		}
+	}
+}
+
+// lookupOCIEnv looks up the environment variable with the given key.
+func lookupOCIEnv(spec *oci.Spec, key string) (string, bool) {
+	if spec.Process == nil {
+		return "", false
+	}
+	for _, e := range spec.Process.Env {
+		if envKey(e) == key {
+			return e, true
+		}
+	}
+	return "", false
+}
+
+// lookupOCIDevice looks up the device with the given container path.
+func lookupOCIDevice(spec *oci.Spec, path string) (*oci.LinuxDevice, bool) {
+	if spec.Linux == nil {
+		return nil, false
+	}
+	for _, d := range spec.Linux.Devices {
+		if d.Path == path {
+			dev := d
+			return &dev, true
+		}
+	}
+	return nil, false
+}
+
+// lookupOCIMount looks up the mount with the given destination.
+func lookupOCIMount(spec *oci.Spec, destination string) (*oci.Mount, bool) {
+	for _, m := range spec.Mounts {
+		if m.Destination == destination {
+			mnt := m
+			return &mnt, true
+		}
+	}
+	return nil, false
+}
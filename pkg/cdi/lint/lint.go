@@ -0,0 +1,227 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package lint checks CDI Specs for likely mistakes. Unlike validation,
+// which rejects invalid Specs, linting reports findings for valid Specs
+// which probably do not do what their author intended, for instance a
+// mount of a host path which does not exist.
+//
+// Every finding is reported by a rule, identified by a rule ID, with the
+// severity of the rule. The built-in rules are listed by Rules. Custom
+// rules can be added using WithRules.
+package lint
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"tags.cncf.io/container-device-interface/pkg/cdi"
+	specs "tags.cncf.io/container-device-interface/specs-go"
+)
+
+// Severity is the severity of a finding.
+type Severity int
+
+const (
+	// Info is the severity of findings which are merely informational.
+	Info Severity = iota
+	// Warning is the severity of findings which are likely mistakes.
+	Warning
+	// Error is the severity of findings which are almost certainly mistakes.
+	Error
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// MarshalText encodes the severity as its name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// ParseSeverity parses the name of a severity.
+func ParseSeverity(name string) (Severity, error) {
+	for _, s := range []Severity{Info, Warning, Error} {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid severity %q", name)
+}
+
+// Rule is a lint rule.
+type Rule struct {
+	// ID uniquely identifies the rule.
+	ID string
+	// Severity of the findings of the rule.
+	Severity Severity
+	// Description of what the rule checks.
+	Description string
+	// Check checks a Spec, reporting findings using the Context.
+	Check func(*Context)
+}
+
+// Finding is a single finding of a rule.
+type Finding struct {
+	// Rule is the ID of the rule reporting the finding.
+	Rule string `json:"rule"`
+	// Severity of the finding.
+	Severity Severity `json:"severity"`
+	// Path of the Spec file.
+	Path string `json:"path"`
+	// Field is the path of the offending field within the Spec.
+	Field string `json:"field,omitempty"`
+	// Message describes the finding.
+	Message string `json:"message"`
+}
+
+// String returns a human-readable description of the finding.
+func (f *Finding) String() string {
+	field := ""
+	if f.Field != "" {
+		field = f.Field + ": "
+	}
+	return fmt.Sprintf("%s: %s: [%s] %s%s", f.Path, f.Severity, f.Rule, field, f.Message)
+}
+
+// Context is passed to rules for checking a Spec.
+type Context struct {
+	// Spec is the Spec being checked.
+	Spec *cdi.Spec
+
+	linter   *Linter
+	rule     *Rule
+	findings []*Finding
+}
+
+// Report reports a finding for the given field of the Spec.
+func (c *Context) Report(field, format string, args ...any) {
+	c.findings = append(c.findings, &Finding{
+		Rule:     c.rule.ID,
+		Severity: c.rule.Severity,
+		Path:     c.Spec.GetPath(),
+		Field:    field,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// HostPath returns the path to look up the given host path at, taking
+// the host root of the Linter into account.
+func (c *Context) HostPath(path string) string {
+	if c.linter.hostRoot == "" {
+		return path
+	}
+	return filepath.Join(c.linter.hostRoot, path)
+}
+
+// ContainerEdits calls fn for the Spec-level container edits and the
+// container edits of each device, with the path of the edits.
+func (c *Context) ContainerEdits(fn func(field string, edits *specs.ContainerEdits)) {
+	fn("containerEdits", &c.Spec.ContainerEdits)
+	for _, d := range c.Spec.Devices {
+		d := d
+		fn(fmt.Sprintf("devices[%q].containerEdits", d.Name), &d.ContainerEdits)
+	}
+}
+
+// Linter checks Specs against a set of rules.
+type Linter struct {
+	rules    []*Rule
+	disabled map[string]bool
+	hostRoot string
+}
+
+// Option is an option for a Linter.
+type Option func(*Linter)
+
+// WithRules returns an option to add custom rules to a Linter.
+func WithRules(rules ...*Rule) Option {
+	return func(l *Linter) {
+		l.rules = append(l.rules, rules...)
+	}
+}
+
+// WithDisabledRules returns an option to disable the rules with the
+// given IDs.
+func WithDisabledRules(ids ...string) Option {
+	return func(l *Linter) {
+		for _, id := range ids {
+			l.disabled[id] = true
+		}
+	}
+}
+
+// WithHostRoot returns an option to set the directory the filesystem of
+// the host is available under. Host paths are looked up relative to it.
+func WithHostRoot(root string) Option {
+	return func(l *Linter) {
+		l.hostRoot = root
+	}
+}
+
+// New creates a Linter with the built-in rules and the given options.
+func New(options ...Option) *Linter {
+	l := &Linter{
+		rules:    Rules(),
+		disabled: map[string]bool{},
+	}
+	for _, o := range options {
+		o(l)
+	}
+	return l
+}
+
+// Lint checks the given Specs. Findings are sorted by Spec file path,
+// then by field.
+func (l *Linter) Lint(specs ...*cdi.Spec) []*Finding {
+	var findings []*Finding
+
+	for _, spec := range specs {
+		for _, rule := range l.rules {
+			if l.disabled[rule.ID] {
+				continue
+			}
+			ctx := &Context{
+				Spec:   spec,
+				linter: l,
+				rule:   rule,
+			}
+			rule.Check(ctx)
+			findings = append(findings, ctx.findings...)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		fi, fj := findings[i], findings[j]
+		if fi.Path != fj.Path {
+			return fi.Path < fj.Path
+		}
+		return fi.Field < fj.Field
+	})
+
+	return findings
+}
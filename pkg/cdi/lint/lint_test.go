@@ -0,0 +1,194 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package lint
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"tags.cncf.io/container-device-interface/pkg/cdi"
+)
+
+func TestLint(t *testing.T) {
+	type testCase struct {
+		name     string
+		spec     string
+		options  []Option
+		findings []string
+	}
+
+	root := t.TempDir()
+	for _, dir := range []string{"dev", "lib", "bin"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0o755))
+	}
+	for file, mode := range map[string]os.FileMode{
+		"dev/dev0":      0o644,
+		"lib/libfoo.so": 0o644,
+		"bin/hook":      0o755,
+		"bin/script":    0o644,
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(root, file), nil, mode))
+	}
+
+	for _, tc := range []*testCase{
+		{
+			name: "clean",
+			spec: `
+cdiVersion: "0.3.0"
+kind: vendor.com/device
+containerEdits:
+  hooks:
+    - hookName: createContainer
+      path: /bin/hook
+devices:
+  - name: dev0
+    containerEdits:
+      env: ["FOO=bar", "BAR=foo"]
+      deviceNodes:
+        - path: /dev/dev0
+      mounts:
+        - hostPath: /lib/libfoo.so
+          containerPath: /lib/libfoo.so
+          options: ["ro", "bind"]
+`,
+		},
+		{
+			name: "all rules",
+			spec: `
+cdiVersion: "0.6.0"
+kind: vendor.com/device
+containerEdits:
+  env: ["FOO=bar", "FOO=baz"]
+  hooks:
+    - hookName: createContainer
+      path: /bin/missing
+    - hookName: createContainer
+      path: /bin/script
+    - hookName: createContainer
+      path: /bin
+devices:
+  - name: dev0
+    containerEdits:
+      deviceNodes:
+        - path: /dev/dev1
+        - path: /dev/dev2
+          hostPath: /dev/missing
+      mounts:
+        - hostPath: /lib/missing.so
+          containerPath: /lib/missing.so
+        - hostPath: tmpfs
+          containerPath: /tmp
+          type: tmpfs
+`,
+			findings: []string{
+				`cdiVersion: info: [version-not-minimal] version 0.6.0 is higher than the required version 0.5.0`,
+				`containerEdits.env[1]: warning: [duplicate-env] "FOO" already set by env[0]`,
+				`containerEdits.hooks[0]: warning: [hook-not-executable] hook "/bin/missing": does not exist`,
+				`containerEdits.hooks[1]: warning: [hook-not-executable] hook "/bin/script" is not executable`,
+				`containerEdits.hooks[2]: warning: [hook-not-executable] hook "/bin" is a directory`,
+				`devices["dev0"].containerEdits.deviceNodes[0]: warning: [device-node-missing] device node "/dev/dev1": does not exist`,
+				`devices["dev0"].containerEdits.deviceNodes[1]: warning: [host-path-missing] host path "/dev/missing": does not exist`,
+				`devices["dev0"].containerEdits.mounts[0]: warning: [host-path-missing] host path "/lib/missing.so": does not exist`,
+				`devices["dev0"].containerEdits.mounts[0]: warning: [mount-without-bind] mount of "/lib/missing.so" has no bind or rbind option`,
+			},
+		},
+		{
+			name: "disabled rules",
+			spec: `
+cdiVersion: "0.6.0"
+kind: vendor.com/device
+devices:
+  - name: dev0
+    containerEdits:
+      env: ["FOO=bar", "FOO=baz"]
+      mounts:
+        - hostPath: /lib/libfoo.so
+          containerPath: /lib/libfoo.so
+`,
+			options: []Option{
+				WithDisabledRules(VersionNotMinimalRule, MountWithoutBindRule),
+			},
+			findings: []string{
+				`devices["dev0"].containerEdits.env[1]: warning: [duplicate-env] "FOO" already set by env[0]`,
+			},
+		},
+		{
+			name: "custom rule",
+			spec: `
+cdiVersion: "0.3.0"
+kind: vendor.com/device
+devices:
+  - name: dev0
+    containerEdits:
+      env: ["FOO=bar"]
+`,
+			options: []Option{
+				WithRules(&Rule{
+					ID:       "no-env",
+					Severity: Error,
+					Check: func(c *Context) {
+						for _, d := range c.Spec.Devices {
+							if len(d.ContainerEdits.Env) > 0 {
+								c.Report("devices[\""+d.Name+"\"]", "has environment")
+							}
+						}
+					},
+				}),
+			},
+			findings: []string{
+				`devices["dev0"]: error: [no-env] has environment`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "spec.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tc.spec), 0o644))
+			spec, err := cdi.ReadSpec(path, 0)
+			require.NoError(t, err)
+
+			options := append([]Option{WithHostRoot(root)}, tc.options...)
+			var findings []string
+			for _, f := range New(options...).Lint(spec) {
+				require.Equal(t, path, f.Path)
+				findings = append(findings, f.Field+": "+f.Severity.String()+": ["+f.Rule+"] "+f.Message)
+			}
+			require.Equal(t, tc.findings, findings)
+		})
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	for _, s := range []Severity{Info, Warning, Error} {
+		parsed, err := ParseSeverity(s.String())
+		require.NoError(t, err)
+		require.Equal(t, s, parsed)
+	}
+	_, err := ParseSeverity("fatal")
+	require.Error(t, err)
+
+	data, err := json.Marshal(&Finding{
+		Rule:     DuplicateEnvRule,
+		Severity: Warning,
+		Path:     "/etc/cdi/spec.yaml",
+		Message:  "duplicate",
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"rule":"duplicate-env","severity":"warning","path":"/etc/cdi/spec.yaml","message":"duplicate"}`, string(data))
+}
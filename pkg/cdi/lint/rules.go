@@ -0,0 +1,206 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package lint
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"tags.cncf.io/container-device-interface/pkg/cdi"
+	specs "tags.cncf.io/container-device-interface/specs-go"
+)
+
+// IDs of the built-in rules.
+const (
+	// HostPathMissingRule reports mounts and device nodes with a host
+	// path which does not exist.
+	HostPathMissingRule = "host-path-missing"
+	// DeviceNodeMissingRule reports device nodes without a host path,
+	// which do not exist on the host.
+	DeviceNodeMissingRule = "device-node-missing"
+	// DuplicateEnvRule reports environment variables set more than once.
+	DuplicateEnvRule = "duplicate-env"
+	// HookNotExecutableRule reports hooks which are not executable.
+	HookNotExecutableRule = "hook-not-executable"
+	// MountWithoutBindRule reports bind mounts without a bind option.
+	MountWithoutBindRule = "mount-without-bind"
+	// VersionNotMinimalRule reports Specs with a version higher than
+	// required for their content.
+	VersionNotMinimalRule = "version-not-minimal"
+)
+
+// Rules returns the built-in rules.
+func Rules() []*Rule {
+	return []*Rule{
+		{
+			ID:          HostPathMissingRule,
+			Severity:    Warning,
+			Description: "host paths of mounts and device nodes should exist",
+			Check:       checkHostPaths,
+		},
+		{
+			ID:          DeviceNodeMissingRule,
+			Severity:    Warning,
+			Description: "device nodes without a host path should exist on the host",
+			Check:       checkDeviceNodes,
+		},
+		{
+			ID:          DuplicateEnvRule,
+			Severity:    Warning,
+			Description: "environment variables should be set only once",
+			Check:       checkDuplicateEnv,
+		},
+		{
+			ID:          HookNotExecutableRule,
+			Severity:    Warning,
+			Description: "hooks should be executable files",
+			Check:       checkHooks,
+		},
+		{
+			ID:          MountWithoutBindRule,
+			Severity:    Warning,
+			Description: "bind mounts should have a bind or rbind option",
+			Check:       checkMountOptions,
+		},
+		{
+			ID:          VersionNotMinimalRule,
+			Severity:    Info,
+			Description: "the version should be the minimum required version",
+			Check:       checkVersion,
+		},
+	}
+}
+
+func checkHostPaths(c *Context) {
+	c.ContainerEdits(func(field string, edits *specs.ContainerEdits) {
+		for i, m := range edits.Mounts {
+			if !isBindMount(m) {
+				continue
+			}
+			if _, err := os.Stat(c.HostPath(m.HostPath)); err != nil {
+				c.Report(fmt.Sprintf("%s.mounts[%d]", field, i),
+					"host path %q: %v", m.HostPath, statError(err))
+			}
+		}
+		for i, d := range edits.DeviceNodes {
+			if d.HostPath == "" {
+				continue
+			}
+			if _, err := os.Lstat(c.HostPath(d.HostPath)); err != nil {
+				c.Report(fmt.Sprintf("%s.deviceNodes[%d]", field, i),
+					"host path %q: %v", d.HostPath, statError(err))
+			}
+		}
+	})
+}
+
+func checkDeviceNodes(c *Context) {
+	c.ContainerEdits(func(field string, edits *specs.ContainerEdits) {
+		for i, d := range edits.DeviceNodes {
+			if d.HostPath != "" {
+				continue
+			}
+			if _, err := os.Lstat(c.HostPath(d.Path)); err != nil {
+				c.Report(fmt.Sprintf("%s.deviceNodes[%d]", field, i),
+					"device node %q: %v", d.Path, statError(err))
+			}
+		}
+	})
+}
+
+func checkDuplicateEnv(c *Context) {
+	c.ContainerEdits(func(field string, edits *specs.ContainerEdits) {
+		seen := map[string]int{}
+		for i, e := range edits.Env {
+			name, _, _ := strings.Cut(e, "=")
+			if first, ok := seen[name]; ok {
+				c.Report(fmt.Sprintf("%s.env[%d]", field, i),
+					"%q already set by env[%d]", name, first)
+				continue
+			}
+			seen[name] = i
+		}
+	})
+}
+
+func checkHooks(c *Context) {
+	c.ContainerEdits(func(field string, edits *specs.ContainerEdits) {
+		for i, h := range edits.Hooks {
+			f := fmt.Sprintf("%s.hooks[%d]", field, i)
+			info, err := os.Stat(c.HostPath(h.Path))
+			switch {
+			case err != nil:
+				c.Report(f, "hook %q: %v", h.Path, statError(err))
+			case info.IsDir():
+				c.Report(f, "hook %q is a directory", h.Path)
+			case info.Mode().Perm()&0o111 == 0:
+				c.Report(f, "hook %q is not executable", h.Path)
+			}
+		}
+	})
+}
+
+func checkMountOptions(c *Context) {
+	c.ContainerEdits(func(field string, edits *specs.ContainerEdits) {
+		for i, m := range edits.Mounts {
+			if !isBindMount(m) {
+				continue
+			}
+			if !hasBindOption(m) {
+				c.Report(fmt.Sprintf("%s.mounts[%d]", field, i),
+					"mount of %q has no bind or rbind option", m.HostPath)
+			}
+		}
+	})
+}
+
+func checkVersion(c *Context) {
+	required, err := cdi.MinimumRequiredVersion(c.Spec.Spec)
+	if err != nil || required == c.Spec.Version {
+		return
+	}
+	c.Report("cdiVersion", "version %s is higher than the required version %s",
+		c.Spec.Version, required)
+}
+
+// isBindMount checks if a mount is a bind mount, which is the case
+// unless it has a type other than "bind".
+func isBindMount(m *specs.Mount) bool {
+	return m.Type == "" || m.Type == "bind"
+}
+
+// hasBindOption checks if a mount has a bind or rbind option.
+func hasBindOption(m *specs.Mount) bool {
+	for _, o := range m.Options {
+		if o == "bind" || o == "rbind" {
+			return true
+		}
+	}
+	return false
+}
+
+// statError returns a short description of a stat error.
+func statError(err error) string {
+	if os.IsNotExist(err) {
+		return "does not exist"
+	}
+	if pathErr, ok := err.(*os.PathError); ok {
+		return pathErr.Err.Error()
+	}
+	return err.Error()
+}
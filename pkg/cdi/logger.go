@@ -0,0 +1,48 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+// Logger is the interface used by the Cache to report what it does.
+// Messages come with a list of alternating key-value pairs, the same
+// way *slog.Logger from the standard library expects them. Therefore
+// a *slog.Logger can be used as such as a Logger.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// WithLogger returns an option to set the Logger used by the Cache.
+// By default, nothing is logged.
+func WithLogger(logger Logger) Option {
+	return func(c *Cache) error {
+		if logger == nil {
+			logger = nopLogger{}
+		}
+		c.logger = logger
+		return nil
+	}
+}
+
+// nopLogger is a Logger which discards all messages.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
+func (nopLogger) Info(string, ...any)  {}
+func (nopLogger) Warn(string, ...any)  {}
+func (nopLogger) Error(string, ...any) {}
@@ -0,0 +1,196 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	oci "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+// testLogger records all logged messages.
+type testLogger struct {
+	sync.Mutex
+	messages []string
+}
+
+func (l *testLogger) log(level, msg string, args ...any) {
+	l.Lock()
+	defer l.Unlock()
+	l.messages = append(l.messages, level+": "+msg+" "+fmt.Sprint(args...))
+}
+
+func (l *testLogger) Debug(msg string, args ...any) { l.log("DEBUG", msg, args...) }
+func (l *testLogger) Info(msg string, args ...any)  { l.log("INFO", msg, args...) }
+func (l *testLogger) Warn(msg string, args ...any)  { l.log("WARN", msg, args...) }
+func (l *testLogger) Error(msg string, args ...any) { l.log("ERROR", msg, args...) }
+
+func (l *testLogger) has(prefix string) bool {
+	l.Lock()
+	defer l.Unlock()
+	for _, m := range l.messages {
+		if strings.HasPrefix(m, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCacheLogger(t *testing.T) {
+	const (
+		vendor1 = `
+cdiVersion: "0.3.0"
+kind:       "vendor1.com/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV1=VAL1"
+`
+		invalid = `
+cdiVersion: "0.3.0"
+kind:       "vendor2.com/device"
+devices:
+  - name: "dev1"
+`
+	)
+
+	dir, err := createSpecDirs(t,
+		map[string]string{
+			"vendor1.yaml": vendor1,
+			"vendor2.yaml": invalid,
+		},
+		nil,
+	)
+	require.NoError(t, err)
+
+	logger := &testLogger{}
+	cache, err := NewCache(
+		WithSpecDirs(
+			filepath.Join(dir, "etc"),
+			filepath.Join(dir, "run"),
+		),
+		WithAutoRefresh(false),
+		WithLogger(logger),
+	)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	require.True(t, logger.has("WARN: failed to load CDI Spec"))
+	require.True(t, logger.has("DEBUG: refreshed CDI Cache"))
+
+	_, err = cache.InjectDevices(&oci.Spec{}, "vendor1.com/device=dev1")
+	require.NoError(t, err)
+	require.True(t, logger.has("DEBUG: injected CDI devices"))
+
+	_, err = cache.InjectDevices(&oci.Spec{}, "vendor2.com/device=dev1")
+	require.Error(t, err)
+	require.True(t, logger.has("WARN: unresolvable CDI devices"))
+}
+
+func TestCacheContext(t *testing.T) {
+	const (
+		vendor1 = `
+cdiVersion: "0.3.0"
+kind:       "vendor1.com/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV1=VAL1"
+`
+	)
+
+	dir, err := createSpecDirs(t, map[string]string{"vendor1.yaml": vendor1}, nil)
+	require.NoError(t, err)
+
+	cache, err := NewCache(
+		WithSpecDirs(
+			filepath.Join(dir, "etc"),
+			filepath.Join(dir, "run"),
+		),
+		WithAutoRefresh(false),
+	)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, cache.RefreshContext(ctx))
+
+	ociSpec := &oci.Spec{}
+	_, err = cache.InjectDevicesContext(ctx, ociSpec, "vendor1.com/device=dev1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"VENDOR1_DEV1=VAL1"}, ociSpec.Process.Env)
+
+	cancel()
+
+	require.ErrorIs(t, cache.RefreshContext(ctx), context.Canceled)
+	require.Equal(t, []string{"vendor1.com/device=dev1"}, cache.ListDevices())
+
+	ociSpec = &oci.Spec{}
+	_, err = cache.InjectDevicesContext(ctx, ociSpec, "vendor1.com/device=dev1")
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, &oci.Spec{}, ociSpec)
+
+	_, _, err = cache.ResolveEditsContext(ctx, "vendor1.com/device=dev1")
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCacheContextPendingRefresh(t *testing.T) {
+	const (
+		vendor1 = `
+cdiVersion: "0.3.0"
+kind:       "vendor1.com/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV1=VAL1"
+`
+	)
+
+	dir := t.TempDir()
+	cache, err := NewCache(
+		WithSpecDirs(filepath.Join(dir, "run")),
+		WithAutoRefresh(true),
+	)
+	require.NoError(t, err)
+	defer cache.Close()
+	require.Empty(t, cache.ListDevices())
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "run"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "run", "vendor1.yaml"), []byte(vendor1), 0o644))
+
+	// the refresh for the appeared Spec directory gets aborted
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cache.Lock()
+	refreshed, err := cache.refreshIfRequired(ctx, false)
+	cache.Unlock()
+	require.True(t, refreshed)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// so the next call must still refresh
+	require.Equal(t, []string{"vendor1.com/device=dev1"}, cache.ListDevices())
+}
@@ -0,0 +1,88 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"time"
+)
+
+// Metrics is the interface used by the Cache to report statistics
+// about refreshes and device injection. The functions are called with
+// the Cache locked, so they should return quickly and must not call
+// back into the Cache.
+type Metrics interface {
+	// Refreshed is called after each completed refresh of the Cache.
+	Refreshed(*RefreshStats)
+	// Injected is called after each attempt to inject devices.
+	Injected(*InjectionStats)
+}
+
+// RefreshStats describes a completed refresh of the Cache.
+type RefreshStats struct {
+	// Duration is the time it took to refresh the Cache.
+	Duration time.Duration
+	// SpecDirs are the Spec file counts per Spec directory, in the
+	// order of precedence of the directories.
+	SpecDirs []*SpecDirStats
+	// Devices are the numbers of resolvable devices per device kind,
+	// "vendor.com/class".
+	Devices map[string]int
+	// Conflicts is the number of devices ignored because of conflicts.
+	Conflicts int
+	// Errors is the number of errors encountered during the refresh.
+	Errors int
+}
+
+// SpecDirStats are the numbers of Spec files in a Spec directory which
+// were loaded successfully and which failed to load.
+type SpecDirStats struct {
+	Dir    string
+	Loaded int
+	Failed int
+}
+
+// InjectionStats describes an attempt to inject devices.
+type InjectionStats struct {
+	// Duration is the time it took to inject the devices.
+	Duration time.Duration
+	// Devices is the number of devices resolved for injection.
+	Devices int
+	// Unresolved is the number of unresolvable devices.
+	Unresolved int
+	// Conflicts is the number of conflicts detected among the edits.
+	Conflicts int
+	// Failed is true if injection failed.
+	Failed bool
+}
+
+// WithMetrics returns an option to set the Metrics the Cache reports
+// statistics to. By default, no statistics are collected.
+func WithMetrics(metrics Metrics) Option {
+	return func(c *Cache) error {
+		if metrics == nil {
+			metrics = nopMetrics{}
+		}
+		c.metrics = metrics
+		return nil
+	}
+}
+
+// nopMetrics is a Metrics which discards all statistics.
+type nopMetrics struct{}
+
+func (nopMetrics) Refreshed(*RefreshStats)  {}
+func (nopMetrics) Injected(*InjectionStats) {}
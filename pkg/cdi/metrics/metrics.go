@@ -0,0 +1,215 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package metrics provides an expvar-based implementation of the
+// cdi.Metrics interface. The collected metrics can be published using
+// the expvar package and written in the Prometheus text format.
+//
+//	m := metrics.New()
+//	expvar.Publish("cdi", m.Var())
+//	cache, err := cdi.NewCache(cdi.WithMetrics(m))
+//	...
+//	m.WritePrometheus(w)
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"tags.cncf.io/container-device-interface/pkg/cdi"
+	"tags.cncf.io/container-device-interface/pkg/parser"
+)
+
+// Metrics collects CDI Cache metrics in expvar variables.
+type Metrics struct {
+	sync.Mutex
+	vars *expvar.Map
+
+	refreshes        *expvar.Int
+	refreshErrors    *expvar.Int
+	refreshSeconds   *expvar.Float
+	specsLoaded      *expvar.Map
+	specsFailed      *expvar.Map
+	devices          *expvar.Map
+	deviceConflicts  *expvar.Int
+	injections       *expvar.Int
+	injectionErrors  *expvar.Int
+	injectionSeconds *expvar.Float
+	unresolved       *expvar.Int
+	editConflicts    *expvar.Int
+}
+
+var _ cdi.Metrics = &Metrics{}
+
+// New creates a new set of metrics. The metrics are not published.
+// Use Var() to publish them using expvar.
+func New() *Metrics {
+	m := &Metrics{
+		vars:             new(expvar.Map).Init(),
+		refreshes:        new(expvar.Int),
+		refreshErrors:    new(expvar.Int),
+		refreshSeconds:   new(expvar.Float),
+		specsLoaded:      new(expvar.Map).Init(),
+		specsFailed:      new(expvar.Map).Init(),
+		devices:          new(expvar.Map).Init(),
+		deviceConflicts:  new(expvar.Int),
+		injections:       new(expvar.Int),
+		injectionErrors:  new(expvar.Int),
+		injectionSeconds: new(expvar.Float),
+		unresolved:       new(expvar.Int),
+		editConflicts:    new(expvar.Int),
+	}
+
+	m.vars.Set("refreshes", m.refreshes)
+	m.vars.Set("refreshErrors", m.refreshErrors)
+	m.vars.Set("refreshSeconds", m.refreshSeconds)
+	m.vars.Set("specsLoaded", m.specsLoaded)
+	m.vars.Set("specsFailed", m.specsFailed)
+	m.vars.Set("devices", m.devices)
+	m.vars.Set("deviceConflicts", m.deviceConflicts)
+	m.vars.Set("injections", m.injections)
+	m.vars.Set("injectionErrors", m.injectionErrors)
+	m.vars.Set("injectionSeconds", m.injectionSeconds)
+	m.vars.Set("unresolvedDevices", m.unresolved)
+	m.vars.Set("editConflicts", m.editConflicts)
+
+	return m
+}
+
+// Var returns all metrics as a single expvar variable.
+func (m *Metrics) Var() expvar.Var {
+	return m.vars
+}
+
+// Refreshed updates the metrics for a Cache refresh.
+func (m *Metrics) Refreshed(s *cdi.RefreshStats) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.refreshes.Add(1)
+	if s.Errors > 0 {
+		m.refreshErrors.Add(1)
+	}
+	m.refreshSeconds.Add(s.Duration.Seconds())
+
+	m.specsLoaded.Init()
+	m.specsFailed.Init()
+	for _, d := range s.SpecDirs {
+		m.specsLoaded.Add(d.Dir, int64(d.Loaded))
+		m.specsFailed.Add(d.Dir, int64(d.Failed))
+	}
+
+	m.devices.Init()
+	for kind, count := range s.Devices {
+		m.devices.Add(kind, int64(count))
+	}
+	m.deviceConflicts.Set(int64(s.Conflicts))
+}
+
+// Injected updates the metrics for a device injection.
+func (m *Metrics) Injected(s *cdi.InjectionStats) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.injections.Add(1)
+	if s.Failed {
+		m.injectionErrors.Add(1)
+	}
+	m.injectionSeconds.Add(s.Duration.Seconds())
+	m.unresolved.Add(int64(s.Unresolved))
+	m.editConflicts.Add(int64(s.Conflicts))
+}
+
+// WritePrometheus writes the metrics in the Prometheus text format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.Lock()
+	defer m.Unlock()
+
+	p := &promWriter{w: w}
+
+	p.header("cdi_refresh_total", "counter", "Number of CDI Cache refreshes.")
+	p.sample("cdi_refresh_total", "", m.refreshes.String())
+	p.header("cdi_refresh_errors_total", "counter", "Number of CDI Cache refreshes with errors.")
+	p.sample("cdi_refresh_errors_total", "", m.refreshErrors.String())
+	p.header("cdi_refresh_duration_seconds", "summary", "Time spent refreshing the CDI Cache.")
+	p.sample("cdi_refresh_duration_seconds_sum", "", m.refreshSeconds.String())
+	p.sample("cdi_refresh_duration_seconds_count", "", m.refreshes.String())
+
+	p.header("cdi_spec_files", "gauge", "Number of CDI Spec files per directory and state.")
+	m.specsLoaded.Do(func(kv expvar.KeyValue) {
+		p.sample("cdi_spec_files", labels("dir", kv.Key, "state", "loaded"), kv.Value.String())
+	})
+	m.specsFailed.Do(func(kv expvar.KeyValue) {
+		p.sample("cdi_spec_files", labels("dir", kv.Key, "state", "failed"), kv.Value.String())
+	})
+
+	p.header("cdi_devices", "gauge", "Number of CDI devices per vendor and class.")
+	m.devices.Do(func(kv expvar.KeyValue) {
+		vendor, class := parser.ParseQualifier(kv.Key)
+		p.sample("cdi_devices", labels("vendor", vendor, "class", class), kv.Value.String())
+	})
+	p.header("cdi_device_conflicts", "gauge", "Number of CDI devices ignored because of conflicts.")
+	p.sample("cdi_device_conflicts", "", m.deviceConflicts.String())
+
+	p.header("cdi_injection_total", "counter", "Number of CDI device injections.")
+	p.sample("cdi_injection_total", "", m.injections.String())
+	p.header("cdi_injection_errors_total", "counter", "Number of failed CDI device injections.")
+	p.sample("cdi_injection_errors_total", "", m.injectionErrors.String())
+	p.header("cdi_injection_duration_seconds", "summary", "Time spent injecting CDI devices.")
+	p.sample("cdi_injection_duration_seconds_sum", "", m.injectionSeconds.String())
+	p.sample("cdi_injection_duration_seconds_count", "", m.injections.String())
+	p.header("cdi_injection_unresolved_devices_total", "counter", "Number of unresolvable CDI devices requested for injection.")
+	p.sample("cdi_injection_unresolved_devices_total", "", m.unresolved.String())
+	p.header("cdi_injection_conflicts_total", "counter", "Number of conflicting edits detected during CDI device injection.")
+	p.sample("cdi_injection_conflicts_total", "", m.editConflicts.String())
+
+	return p.err
+}
+
+// promWriter writes Prometheus text format, remembering the first error.
+type promWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (p *promWriter) header(name, kind, help string) {
+	p.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (p *promWriter) sample(name, labels, value string) {
+	p.printf("%s%s %s\n", name, labels, value)
+}
+
+func (p *promWriter) printf(format string, args ...any) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = fmt.Fprintf(p.w, format, args...)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels formats the given label name-value pairs.
+func labels(pairs ...string) string {
+	var l []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		l = append(l, pairs[i]+`="`+labelEscaper.Replace(pairs[i+1])+`"`)
+	}
+	return "{" + strings.Join(l, ",") + "}"
+}
@@ -0,0 +1,113 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"tags.cncf.io/container-device-interface/pkg/cdi"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	m := New()
+
+	m.Refreshed(&cdi.RefreshStats{
+		Duration: 2 * time.Second,
+		SpecDirs: []*cdi.SpecDirStats{
+			{Dir: "/etc/cdi", Loaded: 2, Failed: 1},
+			{Dir: "/var/run/cdi", Loaded: 1},
+		},
+		Devices: map[string]int{
+			"vendor1.com/device": 3,
+			"vendor2.com/gpu":    1,
+		},
+		Conflicts: 1,
+		Errors:    2,
+	})
+	m.Refreshed(&cdi.RefreshStats{
+		Duration: time.Second,
+		SpecDirs: []*cdi.SpecDirStats{
+			{Dir: "/etc/cdi", Loaded: 3},
+			{Dir: `/var/run/"cdi"`, Loaded: 1},
+		},
+		Devices: map[string]int{
+			"vendor1.com/device": 4,
+		},
+	})
+	m.Injected(&cdi.InjectionStats{
+		Duration: 500 * time.Millisecond,
+		Devices:  2,
+	})
+	m.Injected(&cdi.InjectionStats{
+		Duration:   250 * time.Millisecond,
+		Devices:    1,
+		Unresolved: 2,
+		Conflicts:  1,
+		Failed:     true,
+	})
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, m.WritePrometheus(buf))
+	require.Equal(t, `# HELP cdi_refresh_total Number of CDI Cache refreshes.
+# TYPE cdi_refresh_total counter
+cdi_refresh_total 2
+# HELP cdi_refresh_errors_total Number of CDI Cache refreshes with errors.
+# TYPE cdi_refresh_errors_total counter
+cdi_refresh_errors_total 1
+# HELP cdi_refresh_duration_seconds Time spent refreshing the CDI Cache.
+# TYPE cdi_refresh_duration_seconds summary
+cdi_refresh_duration_seconds_sum 3
+cdi_refresh_duration_seconds_count 2
+# HELP cdi_spec_files Number of CDI Spec files per directory and state.
+# TYPE cdi_spec_files gauge
+cdi_spec_files{dir="/etc/cdi",state="loaded"} 3
+cdi_spec_files{dir="/var/run/\"cdi\"",state="loaded"} 1
+cdi_spec_files{dir="/etc/cdi",state="failed"} 0
+cdi_spec_files{dir="/var/run/\"cdi\"",state="failed"} 0
+# HELP cdi_devices Number of CDI devices per vendor and class.
+# TYPE cdi_devices gauge
+cdi_devices{vendor="vendor1.com",class="device"} 4
+# HELP cdi_device_conflicts Number of CDI devices ignored because of conflicts.
+# TYPE cdi_device_conflicts gauge
+cdi_device_conflicts 0
+# HELP cdi_injection_total Number of CDI device injections.
+# TYPE cdi_injection_total counter
+cdi_injection_total 2
+# HELP cdi_injection_errors_total Number of failed CDI device injections.
+# TYPE cdi_injection_errors_total counter
+cdi_injection_errors_total 1
+# HELP cdi_injection_duration_seconds Time spent injecting CDI devices.
+# TYPE cdi_injection_duration_seconds summary
+cdi_injection_duration_seconds_sum 0.75
+cdi_injection_duration_seconds_count 2
+# HELP cdi_injection_unresolved_devices_total Number of unresolvable CDI devices requested for injection.
+# TYPE cdi_injection_unresolved_devices_total counter
+cdi_injection_unresolved_devices_total 2
+# HELP cdi_injection_conflicts_total Number of conflicting edits detected during CDI device injection.
+# TYPE cdi_injection_conflicts_total counter
+cdi_injection_conflicts_total 1
+`, buf.String())
+
+	vars := map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(m.Var().String()), &vars))
+	require.Equal(t, float64(2), vars["refreshes"])
+	require.Equal(t, map[string]any{"vendor1.com/device": float64(4)}, vars["devices"])
+}
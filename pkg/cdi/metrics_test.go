@@ -0,0 +1,125 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	oci "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+// testMetrics records all reported statistics.
+type testMetrics struct {
+	sync.Mutex
+	refreshes  []*RefreshStats
+	injections []*InjectionStats
+}
+
+func (m *testMetrics) Refreshed(s *RefreshStats) {
+	m.Lock()
+	defer m.Unlock()
+	m.refreshes = append(m.refreshes, s)
+}
+
+func (m *testMetrics) Injected(s *InjectionStats) {
+	m.Lock()
+	defer m.Unlock()
+	m.injections = append(m.injections, s)
+}
+
+func TestCacheMetrics(t *testing.T) {
+	const (
+		vendor1 = `
+cdiVersion: "0.3.0"
+kind:       "vendor1.com/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV1=VAL1"
+  - name: "dev2"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV2=VAL2"
+`
+		vendor1Other = `
+cdiVersion: "0.3.0"
+kind:       "vendor1.com/other"
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR1_OTHER=VAL1"
+`
+		broken = `
+cdiVersion: "0.3.0"
+kind:       "vendor2.com/device"
+devices:
+  - name: "dev1"
+`
+	)
+
+	dir, err := createSpecDirs(t,
+		map[string]string{
+			"vendor1.yaml":       vendor1,
+			"vendor1-other.yaml": vendor1Other,
+		},
+		map[string]string{
+			"broken.yaml": broken,
+		},
+	)
+	require.NoError(t, err)
+
+	metrics := &testMetrics{}
+	cache, err := NewCache(
+		WithSpecDirs(
+			filepath.Join(dir, "etc"),
+			filepath.Join(dir, "run"),
+		),
+		WithAutoRefresh(false),
+		WithMetrics(metrics),
+	)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	require.Len(t, metrics.refreshes, 1)
+	stats := metrics.refreshes[0]
+	require.Equal(t,
+		[]*SpecDirStats{
+			{Dir: filepath.Join(dir, "etc"), Loaded: 2},
+			{Dir: filepath.Join(dir, "run"), Failed: 1},
+		},
+		stats.SpecDirs,
+	)
+	require.Equal(t, map[string]int{"vendor1.com/device": 2, "vendor1.com/other": 1}, stats.Devices)
+	require.Equal(t, 1, stats.Errors)
+
+	_, err = cache.InjectDevices(&oci.Spec{}, "vendor1.com/device=dev1", "vendor1.com/other=dev1")
+	require.NoError(t, err)
+	_, err = cache.InjectDevices(&oci.Spec{}, "vendor1.com/device=dev1", "vendor1.com/device=dev3")
+	require.Error(t, err)
+
+	require.Len(t, metrics.injections, 2)
+	require.Equal(t, 2, metrics.injections[0].Devices)
+	require.Equal(t, 0, metrics.injections[0].Unresolved)
+	require.False(t, metrics.injections[0].Failed)
+	require.Equal(t, 1, metrics.injections[1].Unresolved)
+	require.True(t, metrics.injections[1].Failed)
+}
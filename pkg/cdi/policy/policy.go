@@ -0,0 +1,219 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package policy implements a declarative cdi.AdmissionPolicy. Policies
+// are loaded from YAML or JSON files like this one:
+//
+//	# host paths which must not be mounted, along with anything below
+//	# them, exact or glob patterns
+//	deniedMounts:
+//	  - /
+//	  - /proc
+//	  - /etc
+//	  - /var/run/docker.sock
+//	  - /run/docker.sock
+//	# directories hook executables must be located in
+//	allowedHookDirs:
+//	  - /usr/bin
+//	# maximum number of devices injected into a single container
+//	maxDevices: 8
+//
+// CDI container edits cannot add capabilities to a container, therefore
+// there are no rules for capabilities.
+//
+// A policy is enabled for a cdi.Cache using cdi.WithAdmissionPolicy().
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+	"tags.cncf.io/container-device-interface/pkg/cdi"
+)
+
+const (
+	// DeniedMountsRule is the name of the rule for denied mounts.
+	DeniedMountsRule = "deniedMounts"
+	// AllowedHookDirsRule is the name of the rule for hook locations.
+	AllowedHookDirsRule = "allowedHookDirs"
+	// MaxDevicesRule is the name of the rule for the number of devices.
+	MaxDevicesRule = "maxDevices"
+)
+
+// Policy is a declarative admission policy for CDI device injection.
+type Policy struct {
+	// DeniedMounts are host paths which must not be mounted into a
+	// container. Entries are matched against the cleaned host path of
+	// mounts and device nodes, and against all its parent directories,
+	// either exactly or as a glob pattern (see path.Match). An entry thus
+	// denies a whole subtree, except for '/', which only denies the root
+	// directory itself.
+	DeniedMounts []string `json:"deniedMounts,omitempty"`
+	// AllowedHookDirs are the directories hook executables must be in.
+	// If empty, hooks are not restricted.
+	AllowedHookDirs []string `json:"allowedHookDirs,omitempty"`
+	// MaxDevices is the maximum number of devices injected into a single
+	// container. If zero, the number of devices is not restricted.
+	MaxDevices int `json:"maxDevices,omitempty"`
+}
+
+var _ cdi.AdmissionPolicy = &Policy{}
+
+// Load reads a Policy from the given file.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CDI policy: %w", err)
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CDI policy %q: %w", path, err)
+	}
+	return p, nil
+}
+
+// Parse parses and validates Policy data.
+func Parse(data []byte) (*Policy, error) {
+	p := &Policy{}
+	if err := yaml.UnmarshalStrict(data, p); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal CDI policy: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate the Policy.
+func (p *Policy) Validate() error {
+	for _, m := range p.DeniedMounts {
+		if !filepath.IsAbs(m) {
+			return fmt.Errorf("invalid denied mount %q, not an absolute path", m)
+		}
+		if _, err := path.Match(m, ""); err != nil {
+			return fmt.Errorf("invalid denied mount %q: %w", m, err)
+		}
+	}
+	for _, dir := range p.AllowedHookDirs {
+		if !filepath.IsAbs(dir) {
+			return fmt.Errorf("invalid allowed hook directory %q, not an absolute path", dir)
+		}
+	}
+	if p.MaxDevices < 0 {
+		return fmt.Errorf("invalid maximum number of devices %d", p.MaxDevices)
+	}
+	return nil
+}
+
+// Check the given devices and their merged container edits against the
+// Policy. It returns any violations of the Policy.
+func (p *Policy) Check(devices []string, edits *cdi.ContainerEdits) []*cdi.PolicyViolation {
+	var violations []*cdi.PolicyViolation
+
+	if p.MaxDevices > 0 && len(devices) > p.MaxDevices {
+		violations = append(violations, &cdi.PolicyViolation{
+			Rule: MaxDevicesRule,
+			Message: fmt.Sprintf("%d devices requested, at most %d allowed",
+				len(devices), p.MaxDevices),
+		})
+	}
+
+	if edits == nil || edits.ContainerEdits == nil {
+		return violations
+	}
+
+	for _, m := range edits.Mounts {
+		if denied := p.deniedMount(m.HostPath); denied != "" {
+			violations = append(violations, &cdi.PolicyViolation{
+				Rule:    DeniedMountsRule,
+				Subject: m.HostPath,
+				Message: fmt.Sprintf("mount of %q (to %q) denied by %q",
+					m.HostPath, m.ContainerPath, denied),
+			})
+		}
+	}
+
+	for _, d := range edits.DeviceNodes {
+		hostPath := d.HostPath
+		if hostPath == "" {
+			hostPath = d.Path
+		}
+		if denied := p.deniedMount(hostPath); denied != "" {
+			violations = append(violations, &cdi.PolicyViolation{
+				Rule:    DeniedMountsRule,
+				Subject: hostPath,
+				Message: fmt.Sprintf("device node %q (to %q) denied by %q",
+					hostPath, d.Path, denied),
+			})
+		}
+	}
+
+	for _, h := range edits.Hooks {
+		if !p.allowedHook(h.Path) {
+			violations = append(violations, &cdi.PolicyViolation{
+				Rule:    AllowedHookDirsRule,
+				Subject: h.Path,
+				Message: fmt.Sprintf("%s hook %q outside allowed directories %s",
+					h.HookName, h.Path, strings.Join(p.AllowedHookDirs, ", ")),
+			})
+		}
+	}
+
+	return violations
+}
+
+// deniedMount returns the entry denying the mount of the given host path,
+// either directly or by denying one of its parent directories.
+func (p *Policy) deniedMount(hostPath string) string {
+	hostPath = filepath.Clean(hostPath)
+	for _, denied := range p.DeniedMounts {
+		for dir := hostPath; ; dir = filepath.Dir(dir) {
+			if dir == "/" && hostPath != "/" {
+				break
+			}
+			if filepath.Clean(denied) == dir {
+				return denied
+			}
+			if ok, _ := path.Match(denied, dir); ok {
+				return denied
+			}
+			if dir == filepath.Dir(dir) {
+				break
+			}
+		}
+	}
+	return ""
+}
+
+// allowedHook checks if the hook executable is in an allowed directory.
+func (p *Policy) allowedHook(hookPath string) bool {
+	if len(p.AllowedHookDirs) == 0 {
+		return true
+	}
+	hookPath = filepath.Clean(hookPath)
+	for _, dir := range p.AllowedHookDirs {
+		rel, err := filepath.Rel(filepath.Clean(dir), hookPath)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		return true
+	}
+	return false
+}
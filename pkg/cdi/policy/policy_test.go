@@ -0,0 +1,226 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"tags.cncf.io/container-device-interface/pkg/cdi"
+	"tags.cncf.io/container-device-interface/specs-go"
+)
+
+func TestParse(t *testing.T) {
+	type testCase struct {
+		name    string
+		data    string
+		policy  *Policy
+		invalid bool
+	}
+	for _, tc := range []*testCase{
+		{
+			name:   "empty",
+			data:   "",
+			policy: &Policy{},
+		},
+		{
+			name: "full policy",
+			data: `
+deniedMounts:
+  - /
+  - /proc
+  - /var/run/docker.sock
+allowedHookDirs:
+  - /usr/bin
+maxDevices: 2
+`,
+			policy: &Policy{
+				DeniedMounts:    []string{"/", "/proc", "/var/run/docker.sock"},
+				AllowedHookDirs: []string{"/usr/bin"},
+				MaxDevices:      2,
+			},
+		},
+		{
+			name:    "unknown field",
+			data:    "deniedHooks: [/usr/bin]\n",
+			invalid: true,
+		},
+		{
+			name:    "relative mount",
+			data:    "deniedMounts: [proc]\n",
+			invalid: true,
+		},
+		{
+			name:    "invalid mount pattern",
+			data:    "deniedMounts: [\"/dev/[\"]\n",
+			invalid: true,
+		},
+		{
+			name:    "relative hook directory",
+			data:    "allowedHookDirs: [bin]\n",
+			invalid: true,
+		},
+		{
+			name:    "negative device count",
+			data:    "maxDevices: -1\n",
+			invalid: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			policy, err := Parse([]byte(tc.data))
+			if tc.invalid {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.policy, policy)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	policy := &Policy{
+		DeniedMounts:    []string{"/", "/proc", "/etc", "/var/run/*.sock"},
+		AllowedHookDirs: []string{"/usr/bin", "/opt/vendor/bin"},
+		MaxDevices:      2,
+	}
+
+	type testCase struct {
+		name       string
+		devices    []string
+		edits      *specs.ContainerEdits
+		violations []*cdi.PolicyViolation
+	}
+	for _, tc := range []*testCase{
+		{
+			name:    "no violations",
+			devices: []string{"vendor.com/device=dev1", "vendor.com/device=dev2"},
+			edits: &specs.ContainerEdits{
+				Mounts: []*specs.Mount{
+					{HostPath: "/opt/vendor/config", ContainerPath: "/etc/vendor/config"},
+					{HostPath: "/procfs", ContainerPath: "/procfs"},
+					{HostPath: "/var/run/vendor/socket", ContainerPath: "/run/vendor.sock"},
+				},
+				Hooks: []*specs.Hook{
+					{HookName: "createContainer", Path: "/usr/bin/vendor-hook"},
+					{HookName: "poststop", Path: "/opt/vendor/bin/cleanup"},
+				},
+			},
+		},
+		{
+			name:    "too many devices",
+			devices: []string{"vendor.com/device=dev1", "vendor.com/device=dev2", "vendor.com/device=dev3"},
+			violations: []*cdi.PolicyViolation{
+				{
+					Rule:    MaxDevicesRule,
+					Message: "3 devices requested, at most 2 allowed",
+				},
+			},
+		},
+		{
+			name:    "denied mounts",
+			devices: []string{"vendor.com/device=dev1"},
+			edits: &specs.ContainerEdits{
+				Mounts: []*specs.Mount{
+					{HostPath: "/", ContainerPath: "/host"},
+					{HostPath: "/etc/", ContainerPath: "/host/etc"},
+					{HostPath: "/proc/../proc", ContainerPath: "/host/proc"},
+					{HostPath: "/var/run/docker.sock", ContainerPath: "/var/run/docker.sock"},
+					{HostPath: "/proc/1/root", ContainerPath: "/host/root"},
+					{HostPath: "/etc/vendor/config", ContainerPath: "/etc/vendor/config"},
+				},
+				DeviceNodes: []*specs.DeviceNode{
+					{Path: "/dev/vendor0"},
+					{Path: "/dev/vendor1", HostPath: "/var/run/vendor.sock"},
+				},
+			},
+			violations: []*cdi.PolicyViolation{
+				{
+					Rule:    DeniedMountsRule,
+					Subject: "/",
+					Message: `mount of "/" (to "/host") denied by "/"`,
+				},
+				{
+					Rule:    DeniedMountsRule,
+					Subject: "/etc/",
+					Message: `mount of "/etc/" (to "/host/etc") denied by "/etc"`,
+				},
+				{
+					Rule:    DeniedMountsRule,
+					Subject: "/proc/../proc",
+					Message: `mount of "/proc/../proc" (to "/host/proc") denied by "/proc"`,
+				},
+				{
+					Rule:    DeniedMountsRule,
+					Subject: "/var/run/docker.sock",
+					Message: `mount of "/var/run/docker.sock" (to "/var/run/docker.sock") denied by "/var/run/*.sock"`,
+				},
+				{
+					Rule:    DeniedMountsRule,
+					Subject: "/proc/1/root",
+					Message: `mount of "/proc/1/root" (to "/host/root") denied by "/proc"`,
+				},
+				{
+					Rule:    DeniedMountsRule,
+					Subject: "/etc/vendor/config",
+					Message: `mount of "/etc/vendor/config" (to "/etc/vendor/config") denied by "/etc"`,
+				},
+				{
+					Rule:    DeniedMountsRule,
+					Subject: "/var/run/vendor.sock",
+					Message: `device node "/var/run/vendor.sock" (to "/dev/vendor1") denied by "/var/run/*.sock"`,
+				},
+			},
+		},
+		{
+			name:    "hooks outside allowed directories",
+			devices: []string{"vendor.com/device=dev1"},
+			edits: &specs.ContainerEdits{
+				Hooks: []*specs.Hook{
+					{HookName: "createContainer", Path: "/tmp/hook"},
+					{HookName: "createContainer", Path: "/usr/bin/../../tmp/hook"},
+					{HookName: "prestart", Path: "/usr/bin"},
+				},
+			},
+			violations: []*cdi.PolicyViolation{
+				{
+					Rule:    AllowedHookDirsRule,
+					Subject: "/tmp/hook",
+					Message: `createContainer hook "/tmp/hook" outside allowed directories /usr/bin, /opt/vendor/bin`,
+				},
+				{
+					Rule:    AllowedHookDirsRule,
+					Subject: "/usr/bin/../../tmp/hook",
+					Message: `createContainer hook "/usr/bin/../../tmp/hook" outside allowed directories /usr/bin, /opt/vendor/bin`,
+				},
+				{
+					Rule:    AllowedHookDirsRule,
+					Subject: "/usr/bin",
+					Message: `prestart hook "/usr/bin" outside allowed directories /usr/bin, /opt/vendor/bin`,
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var edits *cdi.ContainerEdits
+			if tc.edits != nil {
+				edits = &cdi.ContainerEdits{ContainerEdits: tc.edits}
+			}
+			require.Equal(t, tc.violations, policy.Check(tc.devices, edits))
+		})
+	}
+}
@@ -0,0 +1,428 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	oci "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+const (
+	// ProvenanceAnnotation is the OCI Spec annotation used to record the
+	// provenance of injected container edits.
+	ProvenanceAnnotation = "provenance.cdi.k8s.io/injected"
+)
+
+// WithInjectionProvenance returns an option to control recording of the
+// provenance of injected container edits. When enabled, every injection
+// records the applied edits and the OCI Spec entries they replaced in the
+// ProvenanceAnnotation of the OCI Spec. EjectDevices() uses this record
+// to later remove the edits of injected devices. By default, provenance
+// is not recorded.
+func WithInjectionProvenance(record bool) Option {
+	return func(c *Cache) error {
+		c.recordProvenance = record
+		return nil
+	}
+}
+
+// EjectDevices removes the container edits of the given previously
+// injected qualified devices from an OCI Spec. The global edits of a
+// Spec are removed once no other injected device of the same Spec is
+// left. Any OCI Spec entries replaced during injection are restored.
+//
+// Only entries recorded in the provenance annotation of the OCI Spec
+// are considered for removal, and an entry is only removed if it is
+// still present in the form it was injected. Therefore injection must
+// have happened with provenance recording enabled. EjectDevices returns
+// any devices without recorded provenance and an error if ejection
+// fails for any of the devices. In this case the OCI Spec is left
+// untouched. Devices can be given as patterns, which are matched against
+// all injected devices.
+func (c *Cache) EjectDevices(ociSpec *oci.Spec, devices ...string) ([]string, error) {
+	var unresolved []string
+
+	if ociSpec == nil {
+		return devices, fmt.Errorf("can't eject devices, nil OCI Spec")
+	}
+
+	c.Lock()
+	closed := c.closed
+	c.Unlock()
+	if closed {
+		return devices, ErrCacheClosed
+	}
+
+	records, err := readProvenance(ociSpec)
+	if err != nil {
+		return devices, err
+	}
+
+	var names []string
+	injected := map[string]struct{}{}
+	for _, r := range records {
+		for _, d := range r.Devices {
+			if _, ok := injected[d]; !ok {
+				injected[d] = struct{}{}
+				names = append(names, d)
+			}
+		}
+	}
+
+	devices, unresolved = expandDevicePatterns(devices, names)
+	eject := map[string]struct{}{}
+	for _, d := range devices {
+		if _, ok := injected[d]; !ok {
+			unresolved = append(unresolved, d)
+			continue
+		}
+		eject[d] = struct{}{}
+	}
+
+	if unresolved != nil {
+		return unresolved, fmt.Errorf("no injection provenance for CDI devices %s",
+			strings.Join(unresolved, ", "))
+	}
+
+	// Spec edits are shared by all devices of the Spec, across records.
+	// Records are ejected in reverse order of injection, so that entries
+	// replaced by a later injection get restored before being removed.
+	var (
+		specsLeft = remainingSpecs(records, injected, eject)
+		remaining []*InjectionReport
+	)
+	for i := len(records) - 1; i >= 0; i-- {
+		if r := records[i].eject(ociSpec, eject, specsLeft); r != nil {
+			remaining = append([]*InjectionReport{r}, remaining...)
+		}
+	}
+
+	if err := writeProvenance(ociSpec, remaining); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// readProvenance reads the injection provenance recorded in an OCI Spec.
+func readProvenance(ociSpec *oci.Spec) ([]*InjectionReport, error) {
+	var records []*InjectionReport
+
+	value, ok := ociSpec.Annotations[ProvenanceAnnotation]
+	if !ok || value == "" {
+		return nil, nil
+	}
+
+	if err := json.Unmarshal([]byte(value), &records); err != nil {
+		return nil, fmt.Errorf("failed to parse CDI injection provenance: %w", err)
+	}
+
+	return records, nil
+}
+
+// writeProvenance writes the given injection provenance into an OCI Spec.
+func writeProvenance(ociSpec *oci.Spec, records []*InjectionReport) error {
+	if len(records) == 0 {
+		delete(ociSpec.Annotations, ProvenanceAnnotation)
+		if len(ociSpec.Annotations) == 0 {
+			ociSpec.Annotations = nil
+		}
+		return nil
+	}
+
+	value, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("failed to record CDI injection provenance: %w", err)
+	}
+
+	if ociSpec.Annotations == nil {
+		ociSpec.Annotations = make(map[string]string)
+	}
+	ociSpec.Annotations[ProvenanceAnnotation] = string(value)
+
+	return nil
+}
+
+// recordProvenance appends the given report to the injection provenance
+// recorded in an OCI Spec.
+func recordProvenance(ociSpec *oci.Spec, report *InjectionReport) error {
+	records, err := readProvenance(ociSpec)
+	if err != nil {
+		return err
+	}
+
+	record := *report
+	record.Conflicts = nil
+
+	return writeProvenance(ociSpec, append(records, &record))
+}
+
+// remainingSpecs returns the Specs with injected devices left after
+// ejecting the given devices. Since device edits can't be empty, every
+// injected device has edits recorded, which tell its Spec.
+func remainingSpecs(records []*InjectionReport, injected, eject map[string]struct{}) map[string]struct{} {
+	left := map[string]struct{}{}
+
+	for _, r := range records {
+		for _, e := range r.Edits {
+			if _, ok := injected[e.Device]; !ok {
+				continue
+			}
+			if _, ok := eject[e.Device]; !ok {
+				left[e.Spec] = struct{}{}
+			}
+		}
+	}
+
+	return left
+}
+
+// eject removes the edits of the given devices recorded in this report
+// from the OCI Spec. Global Spec edits are only removed for Specs not
+// among specsLeft. It returns the remainder of the report, or nil if
+// nothing remains.
+func (r *InjectionReport) eject(ociSpec *oci.Spec, devices, specsLeft map[string]struct{}) *InjectionReport {
+	var (
+		left     = &InjectionReport{}
+		restore  = map[*ReplacedEntry]struct{}{}
+		sortMnts bool
+	)
+
+	for _, d := range r.Devices {
+		if _, ok := devices[d]; !ok {
+			left.Devices = append(left.Devices, d)
+		}
+	}
+
+	for _, e := range r.Edits {
+		keep := false
+		if e.Scope == SpecScope {
+			_, keep = specsLeft[e.Spec]
+		} else {
+			_, eject := devices[e.Device]
+			keep = !eject
+		}
+		if keep {
+			left.Edits = append(left.Edits, e)
+			continue
+		}
+
+		e.remove(ociSpec)
+
+		for _, old := range r.Replaced {
+			if old.Device == e.Device && old.Spec == e.Spec && old.replacedBy(e) {
+				restore[old] = struct{}{}
+			}
+		}
+	}
+
+	for _, old := range r.Replaced {
+		if _, ok := restore[old]; !ok {
+			left.Replaced = append(left.Replaced, old)
+			continue
+		}
+		if old.restore(ociSpec) && old.Kind == MountEdit {
+			sortMnts = true
+		}
+	}
+
+	if sortMnts {
+		sort.Sort(orderedMounts(ociSpec.Mounts))
+	}
+
+	// keep a record of Spec edits still needed by devices of other records
+	if len(left.Devices) == 0 && len(left.Edits) == 0 {
+		return nil
+	}
+
+	return left
+}
+
+// remove the injected entry of this edit from the OCI Spec, provided
+// that the entry is still present unmodified.
+func (e *AppliedEdit) remove(ociSpec *oci.Spec) {
+	switch e.Kind {
+	case EnvEdit:
+		if ociSpec.Process == nil {
+			return
+		}
+		for i, env := range ociSpec.Process.Env {
+			if env == e.Env {
+				ociSpec.Process.Env = append(ociSpec.Process.Env[:i], ociSpec.Process.Env[i+1:]...)
+				return
+			}
+		}
+
+	case DeviceNodeEdit:
+		if e.DeviceNode == nil {
+			return
+		}
+		d := e.DeviceNode
+		// device nodes injected in rootless mode are bind mounts
+		if removeDeviceMount(ociSpec, d) || ociSpec.Linux == nil {
+			return
+		}
+		for i, dev := range ociSpec.Linux.Devices {
+			if dev.Path == d.Path && dev.Type == d.Type && dev.Major == d.Major && dev.Minor == d.Minor {
+				ociSpec.Linux.Devices = append(ociSpec.Linux.Devices[:i], ociSpec.Linux.Devices[i+1:]...)
+				break
+			}
+		}
+		if ociSpec.Linux.Resources == nil || (d.Type != "b" && d.Type != "c") {
+			return
+		}
+		access := d.Permissions
+		if access == "" {
+			access = "rwm"
+		}
+		rules := ociSpec.Linux.Resources.Devices
+		for i, r := range rules {
+			if !r.Allow || r.Type != d.Type || r.Access != access {
+				continue
+			}
+			if r.Major == nil || *r.Major != d.Major || r.Minor == nil || *r.Minor != d.Minor {
+				continue
+			}
+			ociSpec.Linux.Resources.Devices = append(rules[:i], rules[i+1:]...)
+			return
+		}
+
+	case HookEdit:
+		if ociSpec.Hooks == nil || e.Hook == nil {
+			return
+		}
+		var hooks *[]oci.Hook
+		switch e.Hook.HookName {
+		case PrestartHook:
+			hooks = &ociSpec.Hooks.Prestart
+		case CreateRuntimeHook:
+			hooks = &ociSpec.Hooks.CreateRuntime
+		case CreateContainerHook:
+			hooks = &ociSpec.Hooks.CreateContainer
+		case StartContainerHook:
+			hooks = &ociSpec.Hooks.StartContainer
+		case PoststartHook:
+			hooks = &ociSpec.Hooks.Poststart
+		case PoststopHook:
+			hooks = &ociSpec.Hooks.Poststop
+		default:
+			return
+		}
+		injected := e.Hook.ToOCI()
+		for i, h := range *hooks {
+			if reflect.DeepEqual(h, injected) {
+				*hooks = append((*hooks)[:i], (*hooks)[i+1:]...)
+				return
+			}
+		}
+
+	case MountEdit:
+		if e.Mount == nil {
+			return
+		}
+		for i, m := range ociSpec.Mounts {
+			if m.Destination == e.Mount.ContainerPath && m.Source == e.Mount.HostPath {
+				ociSpec.Mounts = append(ociSpec.Mounts[:i], ociSpec.Mounts[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// replacedBy returns true if this entry was replaced by the given edit.
+func (o *ReplacedEntry) replacedBy(e *AppliedEdit) bool {
+	switch o.Kind {
+	case EnvEdit:
+		return e.Kind == EnvEdit && o.Env != "" && envKey(o.Env) == envKey(e.Env)
+	case DeviceNodeEdit:
+		return e.Kind == DeviceNodeEdit && o.DeviceNode != nil && e.DeviceNode != nil &&
+			o.DeviceNode.Path == e.DeviceNode.Path
+	case MountEdit:
+		if o.Mount == nil {
+			return false
+		}
+		switch e.Kind {
+		case MountEdit:
+			return e.Mount != nil && o.Mount.Destination == e.Mount.ContainerPath
+		case DeviceNodeEdit:
+			// device nodes injected in rootless mode are bind mounts
+			return e.DeviceNode != nil && o.Mount.Destination == e.DeviceNode.Path
+		}
+	}
+	return false
+}
+
+// restore this replaced entry in the OCI Spec, unless an entry with the
+// same key has been added since. Returns true if the entry was restored.
+func (o *ReplacedEntry) restore(ociSpec *oci.Spec) bool {
+	switch o.Kind {
+	case EnvEdit:
+		if o.Env == "" {
+			return false
+		}
+		if _, ok := lookupOCIEnv(ociSpec, envKey(o.Env)); ok {
+			return false
+		}
+		if ociSpec.Process == nil {
+			ociSpec.Process = &oci.Process{}
+		}
+		ociSpec.Process.Env = append(ociSpec.Process.Env, o.Env)
+
+	case DeviceNodeEdit:
+		if o.DeviceNode == nil {
+			return false
+		}
+		if _, ok := lookupOCIDevice(ociSpec, o.DeviceNode.Path); ok {
+			return false
+		}
+		if ociSpec.Linux == nil {
+			ociSpec.Linux = &oci.Linux{}
+		}
+		ociSpec.Linux.Devices = append(ociSpec.Linux.Devices, *o.DeviceNode)
+
+	case MountEdit:
+		if o.Mount == nil {
+			return false
+		}
+		if _, ok := lookupOCIMount(ociSpec, o.Mount.Destination); ok {
+			return false
+		}
+		ociSpec.Mounts = append(ociSpec.Mounts, *o.Mount)
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// envKey returns the name of an environment variable.
+func envKey(env string) string {
+	key, _, _ := strings.Cut(env, "=")
+	return key
+}
+
+// envValue returns the value of an environment variable.
+func envValue(env string) string {
+	_, value, _ := strings.Cut(env, "=")
+	return value
+}
@@ -0,0 +1,323 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"path/filepath"
+	"testing"
+
+	oci "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEjectDevices(t *testing.T) {
+	const (
+		vendor1 = `
+cdiVersion: "0.3.0"
+kind:       "vendor1.com/device"
+containerEdits:
+  env:
+  - VENDOR1_SPEC_VAR1=VAL1
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "ORIG_VAR1=OVERRIDE"
+      deviceNodes:
+      - path: "/dev/vendor1-dev1"
+        type: b
+        major: 10
+        minor: 1
+  - name: "dev2"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV2=VAL2"
+      mounts:
+      - hostPath: "/opt/vendor1/lib"
+        containerPath: "/usr/lib/vendor1"
+`
+		vendor2 = `
+cdiVersion: "0.3.0"
+kind:       "vendor2.com/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      deviceNodes:
+      - path: "/dev/vendor2-dev1"
+        type: c
+        major: 20
+        minor: 1
+        permissions: rw
+      hooks:
+      - hookName: createContainer
+        path: "/usr/local/bin/vendor2-hook"
+        args:
+        - "--verbose"
+`
+	)
+
+	dir, err := createSpecDirs(t,
+		map[string]string{
+			"vendor1.yaml": vendor1,
+			"vendor2.yaml": vendor2,
+		},
+		nil,
+	)
+	require.NoError(t, err)
+
+	cache, err := NewCache(
+		WithSpecDirs(
+			filepath.Join(dir, "etc"),
+			filepath.Join(dir, "run"),
+		),
+		WithAutoRefresh(false),
+		WithInjectionProvenance(true),
+	)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	ociSpec := &oci.Spec{
+		Process: &oci.Process{
+			Env: []string{
+				"USER_VAR=VENDOR1_DEV2=VAL2",
+				"ORIG_VAR1=VAL1",
+			},
+		},
+		Mounts: []oci.Mount{
+			{
+				Source:      "/usr/lib/vendor1",
+				Destination: "/usr/lib/vendor1",
+			},
+		},
+		Linux: &oci.Linux{
+			Devices: []oci.LinuxDevice{
+				{
+					Path: "/dev/vendor1-dev1",
+				},
+			},
+		},
+	}
+
+	_, err = cache.InjectDevices(ociSpec, "vendor1.com/device=dev1", "vendor1.com/device=dev2")
+	require.NoError(t, err)
+	_, err = cache.InjectDevices(ociSpec, "vendor2.com/device=dev1")
+	require.NoError(t, err)
+	require.Contains(t, ociSpec.Annotations, ProvenanceAnnotation)
+
+	// a user-added mount to the same destination must be left alone
+	ociSpec.Mounts = append(ociSpec.Mounts, oci.Mount{
+		Source:      "/home/user/lib",
+		Destination: "/usr/lib/vendor1",
+	})
+
+	unresolved, err := cache.EjectDevices(ociSpec, "vendor1.com/device=dev1", "vendor3.com/device=dev1")
+	require.Error(t, err)
+	require.Equal(t, []string{"vendor3.com/device=dev1"}, unresolved)
+
+	unresolved, err = cache.EjectDevices(ociSpec, "vendor1.com/device=dev1")
+	require.NoError(t, err)
+	require.Nil(t, unresolved)
+	require.Equal(t,
+		[]string{
+			"USER_VAR=VENDOR1_DEV2=VAL2",
+			"ORIG_VAR1=VAL1",
+			"VENDOR1_SPEC_VAR1=VAL1",
+			"VENDOR1_DEV2=VAL2",
+		},
+		ociSpec.Process.Env,
+	)
+	require.Equal(t,
+		[]oci.LinuxDevice{
+			{
+				Path:  "/dev/vendor2-dev1",
+				Type:  "c",
+				Major: 20,
+				Minor: 1,
+			},
+			{
+				Path: "/dev/vendor1-dev1",
+			},
+		},
+		ociSpec.Linux.Devices,
+	)
+
+	_, err = cache.EjectDevices(ociSpec, "vendor1.com/device=dev2", "vendor2.com/device=dev1")
+	require.NoError(t, err)
+	require.Equal(t,
+		[]string{
+			"USER_VAR=VENDOR1_DEV2=VAL2",
+			"ORIG_VAR1=VAL1",
+		},
+		ociSpec.Process.Env,
+	)
+	require.Equal(t,
+		[]oci.Mount{
+			{
+				Source:      "/home/user/lib",
+				Destination: "/usr/lib/vendor1",
+			},
+		},
+		ociSpec.Mounts,
+	)
+	require.Equal(t,
+		[]oci.LinuxDevice{
+			{
+				Path: "/dev/vendor1-dev1",
+			},
+		},
+		ociSpec.Linux.Devices,
+	)
+	require.Empty(t, ociSpec.Linux.Resources.Devices)
+	require.Empty(t, ociSpec.Hooks.CreateContainer)
+	require.Nil(t, ociSpec.Annotations)
+
+	unresolved, err = cache.EjectDevices(ociSpec, "vendor1.com/device=dev1")
+	require.Error(t, err)
+	require.Equal(t, []string{"vendor1.com/device=dev1"}, unresolved)
+}
+
+func TestEjectRestoresEnv(t *testing.T) {
+	const device = "vendor1.com/device=dev1"
+
+	newRecord := func() *InjectionReport {
+		return &InjectionReport{
+			Devices: []string{device},
+			Edits: []*AppliedEdit{
+				{
+					Kind:   EnvEdit,
+					Scope:  DeviceScope,
+					Device: device,
+					Spec:   "/etc/cdi/vendor1.yaml",
+					Env:    "ORIG_VAR1=OVERRIDE",
+				},
+			},
+			Replaced: []*ReplacedEntry{
+				{
+					Kind:   EnvEdit,
+					Device: device,
+					Spec:   "/etc/cdi/vendor1.yaml",
+					Env:    "ORIG_VAR1=VAL1",
+				},
+			},
+		}
+	}
+
+	// the injected variable replaced the original one
+	ociSpec := &oci.Spec{
+		Process: &oci.Process{
+			Env: []string{"USER_VAR=VAL", "ORIG_VAR1=OVERRIDE"},
+		},
+	}
+	require.Nil(t, newRecord().eject(ociSpec, map[string]struct{}{device: {}}, nil))
+	require.Equal(t, []string{"USER_VAR=VAL", "ORIG_VAR1=VAL1"}, ociSpec.Process.Env)
+
+	// the original variable is still present, it must not be duplicated
+	ociSpec = &oci.Spec{
+		Process: &oci.Process{
+			Env: []string{"ORIG_VAR1=VAL1", "ORIG_VAR1=OVERRIDE"},
+		},
+	}
+	require.Nil(t, newRecord().eject(ociSpec, map[string]struct{}{device: {}}, nil))
+	require.Equal(t, []string{"ORIG_VAR1=VAL1"}, ociSpec.Process.Env)
+}
+
+func TestEjectDevicesAcrossRecords(t *testing.T) {
+	const (
+		vendor1 = `
+cdiVersion: "0.3.0"
+kind:       "vendor1.com/device"
+containerEdits:
+  env:
+  - VENDOR1_SPEC_VAR1=VAL1
+  mounts:
+  - hostPath: "/opt/vendor1/lib"
+    containerPath: "/usr/lib/vendor1"
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV1=VAL1"
+  - name: "dev2"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV2=VAL2"
+  - name: "dev3"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV3=VAL3"
+`
+	)
+
+	dir, err := createSpecDirs(t, map[string]string{"vendor1.yaml": vendor1}, nil)
+	require.NoError(t, err)
+
+	cache, err := NewCache(
+		WithSpecDirs(
+			filepath.Join(dir, "etc"),
+			filepath.Join(dir, "run"),
+		),
+		WithAutoRefresh(false),
+		WithInjectionProvenance(true),
+	)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	vendor1Mount := []oci.Mount{
+		{
+			Source:      "/opt/vendor1/lib",
+			Destination: "/usr/lib/vendor1",
+		},
+	}
+
+	ociSpec := &oci.Spec{}
+	_, err = cache.InjectDevices(ociSpec, "vendor1.com/device=dev1")
+	require.NoError(t, err)
+	_, err = cache.InjectDevices(ociSpec, "vendor1.com/device=dev2")
+	require.NoError(t, err)
+
+	// the Spec edits are still needed by dev2, injected separately
+	_, err = cache.EjectDevices(ociSpec, "vendor1.com/device=dev1")
+	require.NoError(t, err)
+	require.Equal(t, vendor1Mount, ociSpec.Mounts)
+	require.Contains(t, ociSpec.Process.Env, "VENDOR1_SPEC_VAR1=VAL1")
+	require.NotContains(t, ociSpec.Process.Env, "VENDOR1_DEV1=VAL1")
+
+	_, err = cache.EjectDevices(ociSpec, "vendor1.com/device=dev2")
+	require.NoError(t, err)
+	require.Empty(t, ociSpec.Mounts)
+	require.Empty(t, ociSpec.Process.Env)
+	require.Nil(t, ociSpec.Annotations)
+
+	// the Spec edits recorded for dev1 are still needed by dev3
+	ociSpec = &oci.Spec{}
+	_, err = cache.InjectDevices(ociSpec, "vendor1.com/device=dev1", "vendor1.com/device=dev3")
+	require.NoError(t, err)
+	_, err = cache.InjectDevices(ociSpec, "vendor1.com/device=dev2")
+	require.NoError(t, err)
+
+	_, err = cache.EjectDevices(ociSpec, "vendor1.com/device=dev1", "vendor1.com/device=dev2")
+	require.NoError(t, err)
+	require.Equal(t, vendor1Mount, ociSpec.Mounts)
+	require.Contains(t, ociSpec.Process.Env, "VENDOR1_SPEC_VAR1=VAL1")
+	require.Contains(t, ociSpec.Process.Env, "VENDOR1_DEV3=VAL3")
+
+	_, err = cache.EjectDevices(ociSpec, "vendor1.com/device=dev3")
+	require.NoError(t, err)
+	require.Empty(t, ociSpec.Mounts)
+	require.Empty(t, ociSpec.Process.Env)
+	require.Nil(t, ociSpec.Annotations)
+}
@@ -17,6 +17,7 @@
 package cdi
 
 import (
+	"context"
 	"sync"
 
 	oci "github.com/opencontainers/runtime-spec/specs-go"
@@ -29,6 +30,11 @@ import (
 //
 // The most commonly used Registry functions are for refreshing the
 // registry and injecting CDI devices into an OCI Spec.
+//
+// Functionality added since the introduction of Registry is available
+// through optional interfaces, such as RegistryCloser, which are
+// implemented by all registries returned by GetRegistry and NewRegistry.
+// Use a type assertion to access these.
 type Registry interface {
 	RegistryResolver
 	RegistryRefresher
@@ -72,6 +78,71 @@ type RegistryResolver interface {
 	InjectDevices(spec *oci.Spec, device ...string) (unresolved []string, err error)
 }
 
+// RegistryCloser is the optional registry interface for releasing the
+// resources held by a registry. Close stops monitoring Spec directories.
+// Once closed, all registry functions which return an error fail with
+// ErrCacheClosed.
+type RegistryCloser interface {
+	Close() error
+}
+
+// RegistryContextRefresher is the optional registry interface for
+// refreshing the registry with a context. RefreshContext does the same
+// as Refresh, aborting the refresh if the context is canceled.
+type RegistryContextRefresher interface {
+	RefreshContext(ctx context.Context) error
+}
+
+// RegistryContextResolver is the optional registry interface for
+// resolving CDI devices with a context. InjectDevicesContext and
+// ResolveEditsContext do the same as InjectDevices and ResolveEdits,
+// taking a context.
+type RegistryContextResolver interface {
+	InjectDevicesContext(ctx context.Context, spec *oci.Spec, device ...string) (unresolved []string, err error)
+	ResolveEditsContext(ctx context.Context, device ...string) (edits *ContainerEdits, unresolved []string, err error)
+}
+
+// RegistryEditsResolver is the optional registry interface for
+// resolving container edits without injecting them. ResolveEdits
+// returns the combined container edits InjectDevices would apply for
+// a set of CDI devices given by qualified name, without applying them
+// to any OCI Spec. It returns the names of any unresolved devices and
+// an error if resolution fails. FillMissingInfo fills in any missing
+// device node information in the resolved edits, looking up the host
+// devices the same way InjectDevices does.
+type RegistryEditsResolver interface {
+	ResolveEdits(device ...string) (edits *ContainerEdits, unresolved []string, err error)
+	FillMissingInfo(edits *ContainerEdits) error
+}
+
+// RegistryReportingResolver is the optional registry interface for
+// injecting CDI devices with a report. InjectDevicesWithReport injects
+// devices like InjectDevices, and returns a report of all applied
+// edits, their origin, and all the pre-existing OCI Spec entries
+// replaced during injection.
+type RegistryReportingResolver interface {
+	InjectDevicesWithReport(spec *oci.Spec, device ...string) (*InjectionReport, error)
+}
+
+// RegistryEjector is the optional registry interface for removing
+// injected CDI devices from an OCI Spec. EjectDevices takes an OCI Spec
+// and removes from it the edits of a set of previously injected CDI
+// devices given by qualified name. It relies on provenance recorded in
+// the OCI Spec during injection. It returns the names of any devices
+// without recorded provenance and an error if ejection fails.
+type RegistryEjector interface {
+	EjectDevices(spec *oci.Spec, device ...string) (unresolved []string, err error)
+}
+
+// RegistryPatternResolver is the optional registry interface for
+// resolving device patterns. ResolveDevicePatterns expands any device
+// patterns among the given device requests to the qualified names of
+// all matching devices. It returns the expanded devices and any patterns
+// without a match.
+type RegistryPatternResolver interface {
+	ResolveDevicePatterns(device ...string) (devices []string, unresolved []string)
+}
+
 // RegistryDeviceDB is the registry interface for querying devices.
 //
 // GetDevice returns the CDI device for the given qualified name. If
@@ -112,15 +183,40 @@ type registry struct {
 	*Cache
 }
 
-var _ Registry = &registry{}
+var (
+	_ Registry                  = &registry{}
+	_ RegistryCloser            = &registry{}
+	_ RegistryContextRefresher  = &registry{}
+	_ RegistryContextResolver   = &registry{}
+	_ RegistryEditsResolver     = &registry{}
+	_ RegistryReportingResolver = &registry{}
+	_ RegistryEjector           = &registry{}
+	_ RegistryPatternResolver   = &registry{}
+)
 
 var (
 	reg      *registry
 	initOnce sync.Once
 )
 
-// GetRegistry returns the CDI registry. If any options are given, those
-// are applied to the registry.
+// NewRegistry creates a new CDI registry with the given options. Unlike
+// the default registry returned by GetRegistry, each registry created
+// by NewRegistry is independent of any other one. Reconfiguring it does
+// not affect other registries. The registry should be closed using
+// RegistryCloser when it is no longer needed.
+func NewRegistry(options ...Option) (Registry, error) {
+	r, err := getRegistry(options...)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetRegistry returns the default CDI registry. The default registry is
+// shared by all callers in the process. If any options are given, those
+// are applied to the registry. Any errors applying the options are not
+// returned. Use NewRegistry to create an independent registry instead.
 func GetRegistry(options ...Option) Registry {
 	var new bool
 	initOnce.Do(func() {
@@ -821,3 +821,52 @@ devices:
 		})
 	}
 }
+
+func TestNewRegistry(t *testing.T) {
+	const (
+		vendor1 = `
+cdiVersion: "0.3.0"
+kind:       "vendor1.com/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV1=VAL1"
+`
+		vendor2 = `
+cdiVersion: "0.3.0"
+kind:       "vendor2.com/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR2_DEV1=VAL1"
+`
+	)
+
+	dir1, err := createSpecDirs(t, map[string]string{"vendor1.yaml": vendor1}, nil)
+	require.NoError(t, err)
+	dir2, err := createSpecDirs(t, map[string]string{"vendor2.yaml": vendor2}, nil)
+	require.NoError(t, err)
+
+	reg1, err := NewRegistry(WithSpecDirs(filepath.Join(dir1, "etc")))
+	require.NoError(t, err)
+	defer reg1.(RegistryCloser).Close()
+
+	reg2, err := NewRegistry(WithSpecDirs(filepath.Join(dir2, "etc")))
+	require.NoError(t, err)
+	defer reg2.(RegistryCloser).Close()
+
+	require.NotSame(t, reg1, reg2)
+	require.NotSame(t, GetRegistry(), reg1)
+	require.Equal(t, []string{"vendor1.com/device=dev1"}, reg1.DeviceDB().ListDevices())
+	require.Equal(t, []string{"vendor2.com/device=dev1"}, reg2.DeviceDB().ListDevices())
+
+	require.NoError(t, reg2.Configure(WithSpecDirs(filepath.Join(dir2, "etc"), filepath.Join(dir1, "etc"))))
+	require.Equal(t, []string{"vendor1.com/device=dev1", "vendor2.com/device=dev1"}, reg2.DeviceDB().ListDevices())
+	require.Equal(t, []string{"vendor1.com/device=dev1"}, reg1.DeviceDB().ListDevices())
+
+	reg, err := NewRegistry(WithConflictPolicy("invalid"))
+	require.Error(t, err)
+	require.Nil(t, reg)
+}
@@ -0,0 +1,123 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"strings"
+
+	oci "github.com/opencontainers/runtime-spec/specs-go"
+	"tags.cncf.io/container-device-interface/specs-go"
+)
+
+// WithRootlessInjection returns an option to inject devices for rootless
+// containers. Runtimes of rootless containers cannot create device nodes
+// or set up cgroup device rules. Therefore in rootless mode device nodes
+// are injected as bind mounts of the host device nodes, and no cgroup
+// device rules are added. Device nodes without write permission are
+// mounted read-only. The host path of every device node must be a block
+// or character device, it is looked up like when filling in missing
+// device node information (see WithHostRoot and WithDeviceStat).
+//
+// The ownership of a bind-mounted device node cannot be changed. Inside
+// the container it is the ownership of the host device node, mapped by
+// the user namespace of the container. Any UID or GID of a device node
+// is therefore ignored in rootless mode. Instead, unless the container
+// process owns the host device node, the group owning it is mapped into
+// the container using the GID mappings of the OCI Spec and added to the
+// additional groups of the process. Host IDs without a mapping are left
+// alone, and without any mappings host IDs are used as they are. Added
+// groups are kept when the devices are ejected.
+func WithRootlessInjection(rootless bool) Option {
+	return func(c *Cache) error {
+		c.rootless = rootless
+		return nil
+	}
+}
+
+// deviceMount returns the bind mount for a device node.
+func deviceMount(d *specs.DeviceNode) oci.Mount {
+	options := []string{"bind", "nosuid"}
+	if d.Permissions != "" && !strings.Contains(d.Permissions, "w") {
+		options = append(options, "ro")
+	}
+	return oci.Mount{
+		Source:      deviceHostPath(d),
+		Destination: d.Path,
+		Type:        "bind",
+		Options:     options,
+	}
+}
+
+// removeDeviceMount removes the bind mount for a device node.
+func removeDeviceMount(ociSpec *oci.Spec, d *specs.DeviceNode) bool {
+	mount := deviceMount(d)
+	for i, m := range ociSpec.Mounts {
+		if m.Destination == mount.Destination && m.Source == mount.Source && m.Type == mount.Type {
+			ociSpec.Mounts = append(ociSpec.Mounts[:i], ociSpec.Mounts[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// addDeviceGroup adds the group owning the host device node at the given
+// host path, mapped into the container, to the additional groups of the
+// container process, unless the process already owns the device node.
+func addDeviceGroup(spec *oci.Spec, host hostDevices, hostPath string) {
+	if spec.Process == nil {
+		return
+	}
+
+	uid, gid, err := host.deviceOwner(hostPath)
+	if err != nil {
+		return
+	}
+
+	var uidMappings, gidMappings []oci.LinuxIDMapping
+	if spec.Linux != nil {
+		uidMappings, gidMappings = spec.Linux.UIDMappings, spec.Linux.GIDMappings
+	}
+
+	user := &spec.Process.User
+	if owner, ok := containerID(uidMappings, uid); ok && owner == user.UID {
+		return
+	}
+	group, ok := containerID(gidMappings, gid)
+	if !ok || group == user.GID {
+		return
+	}
+	for _, g := range user.AdditionalGids {
+		if g == group {
+			return
+		}
+	}
+	user.AdditionalGids = append(user.AdditionalGids, group)
+}
+
+// containerID maps a host ID into the container using the given ID
+// mappings. Without any mappings, host IDs map to themselves.
+func containerID(mappings []oci.LinuxIDMapping, hostID uint32) (uint32, bool) {
+	if len(mappings) == 0 {
+		return hostID, true
+	}
+	for _, m := range mappings {
+		if hostID >= m.HostID && hostID-m.HostID < m.Size {
+			return m.ContainerID + hostID - m.HostID, true
+		}
+	}
+	return 0, false
+}
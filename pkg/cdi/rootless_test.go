@@ -0,0 +1,226 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	oci "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRootlessInjection(t *testing.T) {
+	const (
+		vendor1 = `
+cdiVersion: "0.5.0"
+kind:       "vendor1.com/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      deviceNodes:
+      - path: "/dev/vendor1-dev1"
+        hostPath: "/dev/vendor1/dev1"
+        type: "c"
+        major: 10
+        minor: 1
+        uid: 1000
+        gid: 1000
+  - name: "dev2"
+    containerEdits:
+      deviceNodes:
+      - path: "/dev/vendor1-dev2"
+        hostPath: "/dev/vendor1/dev2"
+        type: "c"
+        major: 10
+        minor: 2
+        permissions: "r"
+      mounts:
+      - hostPath: "/var/lib/vendor1"
+        containerPath: "/var/lib/vendor1"
+        options: ["bind"]
+`
+	)
+
+	dir, err := createSpecDirs(t, map[string]string{"vendor1.yaml": vendor1}, nil)
+	require.NoError(t, err)
+
+	cache, err := NewCache(
+		WithSpecDirs(
+			filepath.Join(dir, "etc"),
+			filepath.Join(dir, "run"),
+		),
+		WithAutoRefresh(false),
+		WithRootlessInjection(true),
+		WithInjectionProvenance(true),
+		WithDeviceStat(func(path string) (string, int64, int64, error) {
+			switch path {
+			case "/dev/vendor1/dev1":
+				return "c", 10, 1, nil
+			case "/dev/vendor1/dev2":
+				return "c", 10, 2, nil
+			}
+			return "", 0, 0, errors.New("not a device node")
+		}),
+		func(c *Cache) error {
+			c.host.owner = func(path string) (uint32, uint32, error) {
+				if path == "/dev/vendor1/dev1" {
+					// owned by the container process
+					return 101000, 0, nil
+				}
+				return 0, 100044, nil
+			}
+			return nil
+		},
+	)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	ociSpec := &oci.Spec{
+		Process: &oci.Process{
+			User: oci.User{UID: 1000, GID: 1000},
+		},
+		Linux: &oci.Linux{
+			UIDMappings: []oci.LinuxIDMapping{{ContainerID: 0, HostID: 100000, Size: 65536}},
+			GIDMappings: []oci.LinuxIDMapping{{ContainerID: 0, HostID: 100000, Size: 65536}},
+			Devices: []oci.LinuxDevice{
+				{Path: "/dev/vendor1-dev1", Type: "c", Major: 10, Minor: 100},
+			},
+		},
+		Mounts: []oci.Mount{
+			{Source: "/dev/null", Destination: "/dev/vendor1-dev2", Type: "bind", Options: []string{"bind"}},
+		},
+	}
+
+	_, err = cache.InjectDevices(ociSpec, "vendor1.com/device=dev1", "vendor1.com/device=dev2")
+	require.NoError(t, err)
+
+	require.Empty(t, ociSpec.Linux.Devices)
+	require.Nil(t, ociSpec.Linux.Resources)
+	require.Equal(t, []uint32{44}, ociSpec.Process.User.AdditionalGids)
+	require.Equal(t,
+		[]oci.Mount{
+			{
+				Source:      "/dev/vendor1/dev1",
+				Destination: "/dev/vendor1-dev1",
+				Type:        "bind",
+				Options:     []string{"bind", "nosuid"},
+			},
+			{
+				Source:      "/dev/vendor1/dev2",
+				Destination: "/dev/vendor1-dev2",
+				Type:        "bind",
+				Options:     []string{"bind", "nosuid", "ro"},
+			},
+			{
+				Source:      "/var/lib/vendor1",
+				Destination: "/var/lib/vendor1",
+				Options:     []string{"bind"},
+			},
+		},
+		ociSpec.Mounts,
+	)
+
+	unresolved, err := cache.EjectDevices(ociSpec, "vendor1.com/device=dev2")
+	require.NoError(t, err)
+	require.Empty(t, unresolved)
+	require.Equal(t,
+		[]oci.Mount{
+			{
+				Source:      "/dev/vendor1/dev1",
+				Destination: "/dev/vendor1-dev1",
+				Type:        "bind",
+				Options:     []string{"bind", "nosuid"},
+			},
+			{
+				Source:      "/dev/null",
+				Destination: "/dev/vendor1-dev2",
+				Type:        "bind",
+				Options:     []string{"bind"},
+			},
+		},
+		ociSpec.Mounts,
+	)
+
+	_, err = cache.EjectDevices(ociSpec, "vendor1.com/device=dev1")
+	require.NoError(t, err)
+	require.Equal(t,
+		[]oci.Mount{
+			{
+				Source:      "/dev/null",
+				Destination: "/dev/vendor1-dev2",
+				Type:        "bind",
+				Options:     []string{"bind"},
+			},
+		},
+		ociSpec.Mounts,
+	)
+	require.Equal(t,
+		[]oci.LinuxDevice{
+			{Path: "/dev/vendor1-dev1", Type: "c", Major: 10, Minor: 100},
+		},
+		ociSpec.Linux.Devices,
+	)
+}
+
+func TestRootlessInjectionNonDevice(t *testing.T) {
+	const (
+		vendor1 = `
+cdiVersion: "0.5.0"
+kind:       "vendor1.com/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      deviceNodes:
+      - path: "/dev/vendor1-dev1"
+        hostPath: "/etc/shadow"
+  - name: "dev2"
+    containerEdits:
+      deviceNodes:
+      - path: "/dev/vendor1-dev2"
+        hostPath: "/run/vendor1.fifo"
+`
+	)
+
+	dir, err := createSpecDirs(t, map[string]string{"vendor1.yaml": vendor1}, nil)
+	require.NoError(t, err)
+
+	cache, err := NewCache(
+		WithSpecDirs(
+			filepath.Join(dir, "etc"),
+			filepath.Join(dir, "run"),
+		),
+		WithAutoRefresh(false),
+		WithRootlessInjection(true),
+		WithDeviceStat(func(path string) (string, int64, int64, error) {
+			if path == "/etc/shadow" {
+				return "", 0, 0, errors.New("not a device node")
+			}
+			return "p", 0, 0, nil
+		}),
+	)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	for _, device := range []string{"vendor1.com/device=dev1", "vendor1.com/device=dev2"} {
+		ociSpec := &oci.Spec{}
+		_, err = cache.InjectDevices(ociSpec, device)
+		require.Error(t, err, device)
+		require.Empty(t, ociSpec.Mounts, device)
+	}
+}
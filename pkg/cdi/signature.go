@@ -0,0 +1,220 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	cdi "tags.cncf.io/container-device-interface/specs-go"
+)
+
+const (
+	// SignatureExt is the file name extension of detached Spec signatures.
+	// The signature of a Spec file is stored next to it, in a file with
+	// the name of the Spec file and this extension appended.
+	SignatureExt = ".sig"
+	// publicKeyExt is the file name extension of trusted public keys.
+	publicKeyExt = ".pem"
+)
+
+var (
+	// ErrSignedSpec is returned when rewriting a signed Spec file would
+	// invalidate its detached signature.
+	ErrSignedSpec = errors.New("rewriting the signed CDI Spec would invalidate its signature")
+)
+
+// WithSpecSignatureKeys returns an option to only load Spec files with
+// a valid detached signature. Signatures are verified against the public
+// keys found in the given directory. Public keys are ed25519 keys stored
+// PEM-encoded in PKIX form in files with a '.pem' extension. The keys are
+// reloaded on every refresh. Spec files without a valid signature are not
+// loaded, and the failure is recorded as a Cache error. An empty key
+// directory turns signature verification off, which is the default.
+func WithSpecSignatureKeys(keyDir string) Option {
+	return func(c *Cache) error {
+		c.signatureKeyDir = keyDir
+		return nil
+	}
+}
+
+// SignatureFile returns the path of the detached signature file for the
+// Spec file with the given path.
+func SignatureFile(specPath string) string {
+	return specPath + SignatureExt
+}
+
+// SignSpec signs CDI Spec data with the given key. The signature covers
+// the canonical JSON encoding of the Spec. It is returned base64-encoded,
+// in the format used for detached signature files.
+func SignSpec(raw *cdi.Spec, key ed25519.PrivateKey) ([]byte, error) {
+	data, err := canonicalSpecData(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	sig := ed25519.Sign(key, data)
+	return []byte(base64.StdEncoding.EncodeToString(sig) + "\n"), nil
+}
+
+// VerifySpec verifies a base64-encoded signature of CDI Spec data. The
+// signature is valid if it is a valid signature for any of the keys.
+func VerifySpec(raw *cdi.Spec, signature []byte, keys []ed25519.PublicKey) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+
+	data, err := canonicalSpecData(raw)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if ed25519.Verify(key, data, sig) {
+			return nil
+		}
+	}
+
+	return errors.New("invalid signature")
+}
+
+// ReadSignatureKeys reads all trusted public keys from the given directory.
+func ReadSignatureKeys(dir string) ([]ed25519.PublicKey, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+publicKeyExt))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var keys []ed25519.PublicKey
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key: %w", err)
+		}
+		key, err := ParsePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key %q: %w", file, err)
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no public keys found in %q", dir)
+	}
+
+	return keys, nil
+}
+
+// ParsePublicKey parses a PEM-encoded PKIX ed25519 public key.
+func ParsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.New("no PEM-encoded public key found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+	return pub, nil
+}
+
+// ParsePrivateKey parses a PEM-encoded PKCS #8 ed25519 private key.
+func ParsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, errors.New("no PEM-encoded private key found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return priv, nil
+}
+
+// verifySpecSignature verifies the detached signature of a loaded Spec.
+func verifySpecSignature(spec *Spec, keys []ed25519.PublicKey) error {
+	path := SignatureFile(spec.GetPath())
+	sig, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("CDI Spec %q is not signed", spec.GetPath())
+		}
+		return fmt.Errorf("failed to read CDI Spec signature: %w", err)
+	}
+	if err := VerifySpec(spec.Spec, sig, keys); err != nil {
+		return fmt.Errorf("failed to verify CDI Spec %q: %w", spec.GetPath(), err)
+	}
+	return nil
+}
+
+// checkSignedRewrite checks if the Spec file with the given path can be
+// rewritten with the given new Spec data. A signed Spec file can only be
+// rewritten if its signature stays valid, which is the case if only its
+// formatting or encoding changes.
+func checkSignedRewrite(path string, old, new *cdi.Spec) error {
+	if _, err := os.Stat(SignatureFile(path)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to check CDI Spec signature: %w", err)
+	}
+
+	oldData, err := canonicalSpecData(old)
+	if err != nil {
+		return err
+	}
+	newData, err := canonicalSpecData(new)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(oldData, newData) {
+		return fmt.Errorf("%q: %w", path, ErrSignedSpec)
+	}
+
+	return nil
+}
+
+// canonicalSpecData returns the canonical encoding of CDI Spec data.
+// This is the JSON encoding of the Spec, which does not depend on the
+// formatting or the encoding of the Spec file.
+func canonicalSpecData(raw *cdi.Spec) ([]byte, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode CDI Spec: %w", err)
+	}
+	return data, nil
+}
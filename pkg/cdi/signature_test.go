@@ -0,0 +1,151 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpecSignatures(t *testing.T) {
+	const (
+		vendor1 = `
+cdiVersion: "0.3.0"
+kind:       "vendor1.com/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR1_DEV1=VAL1"
+`
+		vendor1JSON = `{"cdiVersion":"0.3.0","kind":"vendor1.com/device","devices":[{"name":"dev1","containerEdits":{"env":["VENDOR1_DEV1=VAL1"]}}]}`
+		vendor2     = `
+cdiVersion: "0.3.0"
+kind:       "vendor2.com/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR2_DEV1=VAL1"
+`
+		vendor3 = `
+cdiVersion: "0.3.0"
+kind:       "vendor3.com/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "VENDOR3_DEV1=VAL1"
+`
+	)
+
+	trustedPub, trustedKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	sign := func(data string, key ed25519.PrivateKey) string {
+		raw, err := ParseSpec([]byte(data))
+		require.NoError(t, err)
+		sig, err := SignSpec(raw, key)
+		require.NoError(t, err)
+		return string(sig)
+	}
+
+	// signatures cover the content of a Spec, not its encoding
+	yamlSpec, err := ParseSpec([]byte(vendor1))
+	require.NoError(t, err)
+	jsonSpec, err := ParseSpec([]byte(vendor1JSON))
+	require.NoError(t, err)
+	sig, err := SignSpec(yamlSpec, trustedKey)
+	require.NoError(t, err)
+	require.NoError(t, VerifySpec(jsonSpec, sig, []ed25519.PublicKey{trustedPub}))
+	require.Error(t, VerifySpec(jsonSpec, []byte("garbage"), []ed25519.PublicKey{trustedPub}))
+
+	dir, err := createSpecDirs(t,
+		map[string]string{
+			"vendor1.yaml":     vendor1,
+			"vendor1.yaml.sig": sign(vendor1, trustedKey),
+			"vendor2.yaml":     vendor2,
+			"vendor3.yaml":     vendor3,
+			"vendor3.yaml.sig": sign(vendor3, otherKey),
+		},
+		map[string]string{
+			"vendor1.json":     vendor1JSON,
+			"vendor1.json.sig": sign(vendor1, trustedKey),
+		},
+	)
+	require.NoError(t, err)
+
+	keyDir := filepath.Join(dir, "keys")
+	require.NoError(t, os.Mkdir(keyDir, 0755))
+
+	cache, err := NewCache(
+		WithSpecDirs(
+			filepath.Join(dir, "etc"),
+			filepath.Join(dir, "run"),
+		),
+		WithAutoRefresh(false),
+		WithSpecSignatureKeys(keyDir),
+	)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	// without any keys no Specs can be verified
+	require.Empty(t, cache.ListDevices())
+	require.Len(t, cache.GetErrors(), 4)
+
+	der, err := x509.MarshalPKIXPublicKey(trustedPub)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(keyDir, "trusted.pem"),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644))
+
+	require.Error(t, cache.Refresh())
+	require.Equal(t, []string{"vendor1.com/device=dev1"}, cache.ListDevices())
+	require.Equal(t, []string{"vendor1.yaml", "vendor1.json"}, specNames(cache.GetVendorSpecs("vendor1.com")))
+
+	var failed []string
+	for path := range cache.GetErrors() {
+		failed = append(failed, filepath.Base(path))
+	}
+	sort.Strings(failed)
+	require.Equal(t, []string{"vendor2.yaml", "vendor3.yaml"}, failed)
+
+	// without a key directory signatures are not verified
+	require.NoError(t, cache.Configure(WithSpecSignatureKeys("")))
+	require.Equal(t, []string{
+		"vendor1.com/device=dev1",
+		"vendor2.com/device=dev1",
+		"vendor3.com/device=dev1",
+	}, cache.ListDevices())
+}
+
+func specNames(specs []*Spec) []string {
+	var names []string
+	for _, spec := range specs {
+		names = append(names, filepath.Base(spec.GetPath()))
+	}
+	return names
+}
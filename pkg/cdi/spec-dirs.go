@@ -21,6 +21,8 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+
+	cdi "tags.cncf.io/container-device-interface/specs-go"
 )
 
 const (
@@ -57,19 +59,34 @@ func WithSpecDirs(dirs ...string) Option {
 // scanSpecFunc is a function for processing CDI Spec files.
 type scanSpecFunc func(string, int, *Spec, error) error
 
+// scanConfig is the configuration for scanning Spec directories.
+type scanConfig struct {
+	// validator is used to validate the loaded Specs.
+	validator func(*cdi.Spec) error
+	// trust is the optional trust policy for directories and files.
+	trust *SpecTrustPolicy
+	// maxVersion is the optional maximum Spec version to load.
+	maxVersion string
+}
+
 // ScanSpecDirs scans the given directories looking for CDI Spec files,
 // which are all files with a '.json' or '.yaml' suffix. For every Spec
 // file discovered, ScanSpecDirs loads a Spec from the file then calls
 // the scan function passing it the path to the file, the priority (the
 // index of the directory in the slice of directories given), the Spec
-// itself, and any error encountered while loading the Spec.
+// itself, and any error encountered while loading the Spec. Specs are
+// validated using the configured validator. If a trust policy is
+// configured, untrusted directories and Spec files are not loaded.
+// Instead the scan function is called with the corresponding error.
+// Likewise, if a maximum version is configured, Spec files with a later
+// version are not loaded.
 //
 // Scanning stops once all files have been processed or when the scan
 // function returns an error. The result of ScanSpecDirs is the error
 // returned by the scan function, if any. The special error ErrStopScan
 // can be used to terminate the scan gracefully without ScanSpecDirs
 // returning an error. ScanSpecDirs silently skips any subdirectories.
-func scanSpecDirs(dirs []string, scanFn scanSpecFunc) error {
+func scanSpecDirs(dirs []string, cfg *scanConfig, scanFn scanSpecFunc) error {
 	var (
 		spec *Spec
 		err  error
@@ -87,6 +104,12 @@ func scanSpecDirs(dirs []string, scanFn scanSpecFunc) error {
 			// first call from Walk is for dir itself, others we skip
 			if info.IsDir() {
 				if path == dir {
+					if err := cfg.trust.checkDir(dir); err != nil {
+						if err = scanFn(path, priority, nil, err); err != nil {
+							return err
+						}
+						return filepath.SkipDir
+					}
 					return nil
 				}
 				return filepath.SkipDir
@@ -100,8 +123,17 @@ func scanSpecDirs(dirs []string, scanFn scanSpecFunc) error {
 			if err != nil {
 				return scanFn(path, priority, nil, err)
 			}
+			if cfg.trust == nil {
+				spec, err = readSpec(path, priority, cfg.validator, cfg.maxVersion)
+				return scanFn(path, priority, spec, err)
+			}
 
-			spec, err = ReadSpec(path, priority)
+			// check and parse the same open file, so it can't be swapped
+			data, err := cfg.trust.readFile(dir, path, info)
+			if err != nil {
+				return scanFn(path, priority, nil, err)
+			}
+			spec, err = loadSpec(path, priority, data, cfg.validator, cfg.maxVersion)
 			return scanFn(path, priority, spec, err)
 		})
 
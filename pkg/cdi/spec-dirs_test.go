@@ -187,7 +187,7 @@ devices:
 			}
 
 			dirs := []string{"/no-such-dir", dir}
-			err = scanSpecDirs(dirs, func(path string, prio int, spec *Spec, err error) error {
+			err = scanSpecDirs(dirs, &scanConfig{validator: validateSpec}, func(path string, prio int, spec *Spec, err error) error {
 				name := filepath.Base(path)
 				if err != nil {
 					failure[name] = struct{}{}
@@ -0,0 +1,154 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SpecTrustPolicy describes which Spec files and directories are trusted.
+// With a trust policy in effect, Spec files and directories are rejected
+// if they are writable by group or others, if they are not owned by any
+// of the trusted users, or if they are symbolic links which resolve to a
+// path outside of their Spec directory or within an untrusted directory.
+//
+// Ownership and permissions are only checked on Unix-like systems.
+type SpecTrustPolicy struct {
+	// TrustedUIDs are the IDs of the users trusted to own Spec files and
+	// directories. If empty, only root (UID 0) is trusted.
+	TrustedUIDs []uint32
+}
+
+// WithSpecTrustPolicy returns an option to only load Spec files which
+// are trusted according to the given policy. Any rejected Spec files or
+// directories are recorded as Cache errors. Passing a nil policy turns
+// off the trust checks, which is the default.
+func WithSpecTrustPolicy(policy *SpecTrustPolicy) Option {
+	return func(c *Cache) error {
+		c.trustPolicy = policy
+		return nil
+	}
+}
+
+// checkDir checks if the given Spec directory is trusted.
+func (p *SpecTrustPolicy) checkDir(dir string) error {
+	if p == nil {
+		return nil
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if err := p.checkOwnerAndMode(info); err != nil {
+		return fmt.Errorf("untrusted Spec directory %q: %w", dir, err)
+	}
+
+	return nil
+}
+
+// readFile reads the given Spec file in the given directory if it is
+// trusted. info is the result of os.Lstat() for the file. The checks
+// are done on the opened file, which is then read, so the file can't
+// be swapped for an untrusted one between checking and reading it.
+func (p *SpecTrustPolicy) readFile(dir, path string, info os.FileInfo) ([]byte, error) {
+	name := path
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := p.checkSymlink(dir, path)
+		if err != nil {
+			return nil, err
+		}
+		name = target
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat CDI Spec %q: %w", path, err)
+	}
+	if !st.Mode().IsRegular() {
+		return nil, fmt.Errorf("untrusted Spec file %q: not a regular file", path)
+	}
+	if name == path && !os.SameFile(info, st) {
+		return nil, fmt.Errorf("untrusted Spec file %q: file changed while loading", path)
+	}
+	if err := p.checkOwnerAndMode(st); err != nil {
+		return nil, fmt.Errorf("untrusted Spec file %q: %w", path, err)
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CDI Spec %q: %w", path, err)
+	}
+
+	return data, nil
+}
+
+// checkSymlink checks if the given symlinked Spec file in the given
+// directory resolves to a path within the directory, with all the
+// directories leading to it trusted. It returns the resolved path.
+func (p *SpecTrustPolicy) checkSymlink(dir, path string) (string, error) {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("untrusted Spec file %q: symlink to %q outside %q",
+			path, target, dir)
+	}
+
+	// the Spec directory itself is checked by checkDir()
+	for d := filepath.Dir(target); d != root; d = filepath.Dir(d) {
+		info, err := os.Stat(d)
+		if err != nil {
+			return "", err
+		}
+		if err := p.checkOwnerAndMode(info); err != nil {
+			return "", fmt.Errorf("untrusted Spec file %q: symlink target directory %q: %w",
+				path, d, err)
+		}
+	}
+
+	return target, nil
+}
+
+// isTrustedUID checks if the given user is trusted.
+func (p *SpecTrustPolicy) isTrustedUID(uid uint32) bool {
+	if len(p.TrustedUIDs) == 0 {
+		return uid == 0
+	}
+	for _, trusted := range p.TrustedUIDs {
+		if uid == trusted {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+//go:build !windows
+// +build !windows
+
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpecTrustPolicy(t *testing.T) {
+	const (
+		specTemplate = `
+cdiVersion: "0.3.0"
+kind:       "VENDOR/device"
+devices:
+  - name: "dev1"
+    containerEdits:
+      env:
+      - "DEV1=VAL1"
+`
+	)
+	spec := func(vendor string) string {
+		return strings.Replace(specTemplate, "VENDOR", vendor, 1)
+	}
+
+	uid := uint32(os.Getuid())
+
+	type testCase struct {
+		name    string
+		policy  *SpecTrustPolicy
+		setup   func(t *testing.T, dir string)
+		devices []string
+		errors  []string
+	}
+	for _, tc := range []*testCase{
+		{
+			name: "no policy",
+			setup: func(t *testing.T, dir string) {
+				require.NoError(t, os.Chmod(filepath.Join(dir, "etc", "vendor1.yaml"), 0666))
+			},
+			devices: []string{
+				"vendor1.com/device=dev1",
+				"vendor2.com/device=dev1",
+				"vendor3.com/device=dev1",
+			},
+		},
+		{
+			name:   "trusted",
+			policy: &SpecTrustPolicy{TrustedUIDs: []uint32{uid}},
+			devices: []string{
+				"vendor1.com/device=dev1",
+				"vendor2.com/device=dev1",
+				"vendor3.com/device=dev1",
+			},
+		},
+		{
+			name:   "untrusted owner",
+			policy: &SpecTrustPolicy{TrustedUIDs: []uint32{uid + 1}},
+			errors: []string{
+				"etc",
+				"run",
+			},
+		},
+		{
+			name:   "world-writable file",
+			policy: &SpecTrustPolicy{TrustedUIDs: []uint32{uid}},
+			setup: func(t *testing.T, dir string) {
+				require.NoError(t, os.Chmod(filepath.Join(dir, "etc", "vendor1.yaml"), 0646))
+			},
+			devices: []string{
+				"vendor2.com/device=dev1",
+				"vendor3.com/device=dev1",
+			},
+			errors: []string{
+				"etc/vendor1.yaml",
+			},
+		},
+		{
+			name:   "group-writable directory",
+			policy: &SpecTrustPolicy{TrustedUIDs: []uint32{uid}},
+			setup: func(t *testing.T, dir string) {
+				require.NoError(t, os.Chmod(filepath.Join(dir, "run"), 0775))
+			},
+			devices: []string{
+				"vendor1.com/device=dev1",
+				"vendor2.com/device=dev1",
+			},
+			errors: []string{
+				"run",
+			},
+		},
+		{
+			name:   "symlinks",
+			policy: &SpecTrustPolicy{TrustedUIDs: []uint32{uid}},
+			setup: func(t *testing.T, dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "vendor4.yaml"),
+					[]byte(spec("vendor4.com")), 0644))
+				require.NoError(t, os.Symlink(filepath.Join(dir, "vendor4.yaml"),
+					filepath.Join(dir, "etc", "vendor4.yaml")))
+				require.NoError(t, os.Rename(filepath.Join(dir, "etc", "vendor2.yaml"),
+					filepath.Join(dir, "etc", "vendor2.data")))
+				require.NoError(t, os.Symlink("vendor2.data",
+					filepath.Join(dir, "etc", "vendor2.yaml")))
+			},
+			devices: []string{
+				"vendor1.com/device=dev1",
+				"vendor2.com/device=dev1",
+				"vendor3.com/device=dev1",
+			},
+			errors: []string{
+				"etc/vendor4.yaml",
+			},
+		},
+		{
+			name:   "symlink into untrusted directory",
+			policy: &SpecTrustPolicy{TrustedUIDs: []uint32{uid}},
+			setup: func(t *testing.T, dir string) {
+				data := filepath.Join(dir, "etc", "data")
+				require.NoError(t, os.Mkdir(data, 0755))
+				require.NoError(t, os.Chmod(data, 0777))
+				require.NoError(t, os.Rename(filepath.Join(dir, "etc", "vendor1.yaml"),
+					filepath.Join(data, "vendor1.yaml")))
+				require.NoError(t, os.Symlink(filepath.Join("data", "vendor1.yaml"),
+					filepath.Join(dir, "etc", "vendor1.yaml")))
+			},
+			devices: []string{
+				"vendor2.com/device=dev1",
+				"vendor3.com/device=dev1",
+			},
+			errors: []string{
+				"etc/vendor1.yaml",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := createSpecDirs(t,
+				map[string]string{
+					"vendor1.yaml": spec("vendor1.com"),
+					"vendor2.yaml": spec("vendor2.com"),
+				},
+				map[string]string{
+					"vendor3.yaml": spec("vendor3.com"),
+				},
+			)
+			require.NoError(t, err)
+			if tc.setup != nil {
+				tc.setup(t, dir)
+			}
+
+			cache, err := NewCache(
+				WithSpecDirs(
+					filepath.Join(dir, "etc"),
+					filepath.Join(dir, "run"),
+				),
+				WithAutoRefresh(false),
+				WithSpecTrustPolicy(tc.policy),
+			)
+			require.NoError(t, err)
+			defer cache.Close()
+
+			require.Equal(t, tc.devices, cache.ListDevices())
+
+			var errors []string
+			for path := range cache.GetErrors() {
+				rel, err := filepath.Rel(dir, path)
+				require.NoError(t, err)
+				errors = append(errors, rel)
+			}
+			sort.Strings(errors)
+			require.Equal(t, tc.errors, errors)
+		})
+	}
+}
+
+func TestSpecTrustPolicyReadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vendor.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("cdiVersion: 0.3.0"), 0644))
+	info, err := os.Lstat(path)
+	require.NoError(t, err)
+
+	policy := &SpecTrustPolicy{TrustedUIDs: []uint32{uint32(os.Getuid())}}
+	data, err := policy.readFile(dir, path, info)
+	require.NoError(t, err)
+	require.Equal(t, "cdiVersion: 0.3.0", string(data))
+
+	// a file swapped after being looked up is rejected
+	swapped := filepath.Join(dir, "swapped.yaml")
+	require.NoError(t, os.WriteFile(swapped, []byte("cdiVersion: 0.3.0"), 0644))
+	require.NoError(t, os.Rename(swapped, path))
+	_, err = policy.readFile(dir, path, info)
+	require.Error(t, err)
+}
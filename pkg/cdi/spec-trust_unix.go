@@ -0,0 +1,44 @@
+//go:build !windows
+// +build !windows
+
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkOwnerAndMode checks the owner and permissions of a Spec file or
+// directory.
+func (p *SpecTrustPolicy) checkOwnerAndMode(info os.FileInfo) error {
+	if perm := info.Mode().Perm(); perm&0o022 != 0 {
+		return fmt.Errorf("writable by group or others (mode %#o)", perm)
+	}
+
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("failed to determine owner")
+	}
+	if !p.isTrustedUID(st.Uid) {
+		return fmt.Errorf("owned by untrusted user %d", st.Uid)
+	}
+
+	return nil
+}
@@ -51,17 +51,25 @@ var (
 // for the same fully qualified device.
 type Spec struct {
 	*cdi.Spec
-	vendor   string
-	class    string
-	path     string
-	priority int
-	devices  map[string]*Device
+	vendor    string
+	class     string
+	path      string
+	priority  int
+	devices   map[string]*Device
+	validator func(*cdi.Spec) error
 }
 
 // ReadSpec reads the given CDI Spec file. The resulting Spec is
 // assigned the given priority. If reading or parsing the Spec
 // data fails ReadSpec returns a nil Spec and an error.
 func ReadSpec(path string, priority int) (*Spec, error) {
+	return readSpec(path, priority, validateSpec, "")
+}
+
+// readSpec reads the given CDI Spec file, using the given validator
+// for extra validation of the Spec content. If maxVersion is not empty,
+// Specs with a later version are rejected before being parsed.
+func readSpec(path string, priority int, validator func(*cdi.Spec) error, maxVersion string) (*Spec, error) {
 	data, err := ioutil.ReadFile(path)
 	switch {
 	case os.IsNotExist(err):
@@ -70,6 +78,16 @@ func ReadSpec(path string, priority int) (*Spec, error) {
 		return nil, fmt.Errorf("failed to read CDI Spec %q: %w", path, err)
 	}
 
+	return loadSpec(path, priority, data, validator, maxVersion)
+}
+
+// loadSpec loads a Spec from the given data, read from the given CDI
+// Spec file, like readSpec.
+func loadSpec(path string, priority int, data []byte, validator func(*cdi.Spec) error, maxVersion string) (*Spec, error) {
+	if err := checkMaxVersion(path, data, maxVersion); err != nil {
+		return nil, err
+	}
+
 	raw, err := ParseSpec(data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse CDI Spec %q: %w", path, err)
@@ -78,7 +96,7 @@ func ReadSpec(path string, priority int) (*Spec, error) {
 		return nil, fmt.Errorf("failed to parse CDI Spec %q, no Spec data", path)
 	}
 
-	spec, err := newSpec(raw, path, priority)
+	spec, err := newSpecWithValidator(raw, path, priority, validator)
 	if err != nil {
 		return nil, err
 	}
@@ -91,15 +109,22 @@ func ReadSpec(path string, priority int) (*Spec, error) {
 // priority. If Spec data validation fails newSpec returns a nil
 // Spec and an error.
 func newSpec(raw *cdi.Spec, path string, priority int) (*Spec, error) {
-	err := validateSpec(raw)
+	return newSpecWithValidator(raw, path, priority, validateSpec)
+}
+
+// newSpecWithValidator creates a new Spec like newSpec, using the given
+// validator for extra validation of the Spec content.
+func newSpecWithValidator(raw *cdi.Spec, path string, priority int, validator func(*cdi.Spec) error) (*Spec, error) {
+	err := validator(raw)
 	if err != nil {
 		return nil, err
 	}
 
 	spec := &Spec{
-		Spec:     raw,
-		path:     filepath.Clean(path),
-		priority: priority,
+		Spec:      raw,
+		path:      filepath.Clean(path),
+		priority:  priority,
+		validator: validator,
 	}
 
 	if ext := filepath.Ext(spec.path); ext != ".yaml" && ext != ".json" {
@@ -125,19 +150,17 @@ func (s *Spec) write(overwrite bool) error {
 		err  error
 	)
 
-	err = validateSpec(s.Spec)
-	if err != nil {
+	validator := s.validator
+	if validator == nil {
+		validator = validateSpec
+	}
+	if err = validator(s.Spec); err != nil {
 		return err
 	}
 
-	if filepath.Ext(s.path) == ".yaml" {
-		data, err = yaml.Marshal(s.Spec)
-		data = append([]byte("---\n"), data...)
-	} else {
-		data, err = json.Marshal(s.Spec)
-	}
+	data, err = s.encode()
 	if err != nil {
-		return fmt.Errorf("failed to marshal Spec file: %w", err)
+		return err
 	}
 
 	dir = filepath.Dir(s.path)
@@ -166,6 +189,27 @@ func (s *Spec) write(overwrite bool) error {
 	return err
 }
 
+// encode encodes the Spec data as JSON or YAML, depending on the
+// extension of the Spec file.
+func (s *Spec) encode() ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	if filepath.Ext(s.path) == ".yaml" {
+		data, err = yaml.Marshal(s.Spec)
+		data = append([]byte("---\n"), data...)
+	} else {
+		data, err = json.Marshal(s.Spec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Spec file: %w", err)
+	}
+
+	return data, nil
+}
+
 // GetVendor returns the vendor of this Spec.
 func (s *Spec) GetVendor() string {
 	return s.vendor
@@ -262,15 +306,83 @@ func ParseSpec(data []byte) (*cdi.Spec, error) {
 	return raw, nil
 }
 
+// CloneSpec returns a deep copy of the given raw CDI Spec, which shares
+// no data with the original.
+func CloneSpec(raw *cdi.Spec) *cdi.Spec {
+	spec := *raw
+	spec.Annotations = cloneAnnotations(raw.Annotations)
+	spec.ContainerEdits = *(&ContainerEdits{ContainerEdits: &raw.ContainerEdits}).clone().ContainerEdits
+	if raw.Devices != nil {
+		spec.Devices = make([]cdi.Device, len(raw.Devices))
+		for i, d := range raw.Devices {
+			d.Annotations = cloneAnnotations(d.Annotations)
+			d.ContainerEdits = *(&ContainerEdits{ContainerEdits: &d.ContainerEdits}).clone().ContainerEdits
+			spec.Devices[i] = d
+		}
+	}
+	return &spec
+}
+
+// cloneAnnotations returns a copy of the given annotations.
+func cloneAnnotations(annotations map[string]string) map[string]string {
+	if annotations == nil {
+		return nil
+	}
+	c := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		c[k] = v
+	}
+	return c
+}
+
 // SetSpecValidator sets a CDI Spec validator function. This function
 // is used for extra CDI Spec content validation whenever a Spec file
-// loaded (using ReadSpec() or written (using WriteSpec()).
+// loaded (using ReadSpec() or written (using WriteSpec()). The function
+// is global. It is not used by any Cache which has its own validators
+// set using WithSpecValidator().
 func SetSpecValidator(fn func(*cdi.Spec) error) {
 	validatorLock.Lock()
 	defer validatorLock.Unlock()
 	specValidator = fn
 }
 
+// WithSpecValidator returns an option to set the CDI Spec validator
+// functions of a Cache. These are used instead of the global validator
+// set by SetSpecValidator() for extra CDI Spec content validation when
+// the Cache loads or writes Spec files. Multiple validators are chained
+// and run in the given order. Validation fails with the first validator
+// failing. Without any (non-nil) validators the Cache reverts to using
+// the global validator.
+func WithSpecValidator(fns ...func(*cdi.Spec) error) Option {
+	return func(c *Cache) error {
+		var chain specValidatorChain
+		for _, fn := range fns {
+			if fn != nil {
+				chain = append(chain, fn)
+			}
+		}
+		if len(chain) == 0 {
+			c.validator = validateSpec
+		} else {
+			c.validator = chain.validate
+		}
+		return nil
+	}
+}
+
+// specValidatorChain is a chain of Spec validator functions.
+type specValidatorChain []func(*cdi.Spec) error
+
+// validate the Spec using all validators in the chain.
+func (v specValidatorChain) validate(raw *cdi.Spec) error {
+	for _, fn := range v {
+		if err := fn(raw); err != nil {
+			return fmt.Errorf("Spec validation failed: %w", err)
+		}
+	}
+	return nil
+}
+
 // validateSpec validates the Spec using the extneral validator.
 func validateSpec(raw *cdi.Spec) error {
 	validatorLock.RLock()
@@ -680,3 +680,51 @@ func TestRequiredVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestCloneSpec(t *testing.T) {
+	mode := os.FileMode(0o600)
+	raw := &cdi.Spec{
+		Version:     "0.6.0",
+		Kind:        "vendor.com/device",
+		Annotations: map[string]string{"key": "value"},
+		ContainerEdits: cdi.ContainerEdits{
+			Env: []string{"FOO=bar"},
+			Hooks: []*cdi.Hook{
+				{HookName: "createContainer", Path: "/bin/hook", Args: []string{"hook"}},
+			},
+		},
+		Devices: []cdi.Device{
+			{
+				Name:        "dev0",
+				Annotations: map[string]string{"key": "value"},
+				ContainerEdits: cdi.ContainerEdits{
+					DeviceNodes: []*cdi.DeviceNode{
+						{Path: "/dev/dev0", FileMode: &mode},
+					},
+					Mounts: []*cdi.Mount{
+						{HostPath: "/opt/lib", ContainerPath: "/usr/lib", Options: []string{"ro"}},
+					},
+				},
+			},
+		},
+	}
+
+	clone := CloneSpec(raw)
+	require.Equal(t, raw, clone)
+
+	clone.Annotations["key"] = "modified"
+	clone.ContainerEdits.Env[0] = "FOO=modified"
+	clone.ContainerEdits.Hooks[0].Args[0] = "modified"
+	clone.Devices[0].Name = "modified"
+	clone.Devices[0].Annotations["key"] = "modified"
+	*clone.Devices[0].ContainerEdits.DeviceNodes[0].FileMode = 0o666
+	clone.Devices[0].ContainerEdits.Mounts[0].Options[0] = "rw"
+
+	require.Equal(t, "value", raw.Annotations["key"])
+	require.Equal(t, "FOO=bar", raw.ContainerEdits.Env[0])
+	require.Equal(t, "hook", raw.ContainerEdits.Hooks[0].Args[0])
+	require.Equal(t, "dev0", raw.Devices[0].Name)
+	require.Equal(t, "value", raw.Devices[0].Annotations["key"])
+	require.Equal(t, os.FileMode(0o600), *raw.Devices[0].ContainerEdits.DeviceNodes[0].FileMode)
+	require.Equal(t, "ro", raw.Devices[0].ContainerEdits.Mounts[0].Options[0])
+}
@@ -0,0 +1,259 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"tags.cncf.io/container-device-interface/internal/multierror"
+	cdi "tags.cncf.io/container-device-interface/specs-go"
+)
+
+const (
+	// TransientOwnerAnnotation is the Spec annotation for the ID of the
+	// owner of a transient Spec, for instance a container ID.
+	TransientOwnerAnnotation = AnnotationPrefix + "transient-owner"
+	// TransientCreatedAnnotation is the Spec annotation for the creation
+	// time of a transient Spec, in RFC 3339 format.
+	TransientCreatedAnnotation = AnnotationPrefix + "transient-created"
+	// TransientTTLAnnotation is the Spec annotation for the time to live
+	// of a transient Spec, in time.ParseDuration format.
+	TransientTTLAnnotation = AnnotationPrefix + "transient-ttl"
+)
+
+// TransientSpecInfo is the owner metadata of a transient Spec.
+type TransientSpecInfo struct {
+	// Owner is the ID of the entity the Spec is tied to.
+	Owner string
+	// Created is the time the Spec was written.
+	Created time.Time
+	// TTL is the time to live of the Spec. Zero means no expiry.
+	TTL time.Duration
+}
+
+// Expired checks if the transient Spec has expired at the given time.
+func (i *TransientSpecInfo) Expired(now time.Time) bool {
+	return i.TTL > 0 && now.After(i.Created.Add(i.TTL))
+}
+
+// GetTransientSpecInfo returns the owner metadata of a transient Spec
+// written by a TransientSpecManager. It returns nil for other Specs, and
+// an error if the metadata is malformed. A time to live without a creation
+// time is malformed, since the expiry of the Spec can't be determined.
+func GetTransientSpecInfo(raw *cdi.Spec) (*TransientSpecInfo, error) {
+	owner, ok := raw.Annotations[TransientOwnerAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	info := &TransientSpecInfo{Owner: owner}
+	if created, ok := raw.Annotations[TransientCreatedAnnotation]; ok {
+		t, err := time.Parse(time.RFC3339, created)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transient Spec creation time %q: %w", created, err)
+		}
+		info.Created = t
+	}
+	if ttl, ok := raw.Annotations[TransientTTLAnnotation]; ok {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transient Spec TTL %q: %w", ttl, err)
+		}
+		info.TTL = d
+	}
+	if info.TTL > 0 && info.Created.IsZero() {
+		return nil, errors.New("invalid transient Spec, TTL without creation time")
+	}
+
+	return info, nil
+}
+
+// OwnerAliveFunc checks if the owner with the given ID of a transient
+// Spec is still alive.
+type OwnerAliveFunc func(owner string) (bool, error)
+
+// TransientSpecManager writes and removes transient Specs, and garbage
+// collects stale ones. Transient Specs are annotated with the ID of their
+// owner, their creation time and an optional time to live. If the owner
+// fails to remove its Specs, for instance because it crashed, these are
+// removed by GC once they expire or once their owner is gone.
+type TransientSpecManager struct {
+	specDB RegistrySpecDB
+	now    func() time.Time
+}
+
+// NewTransientSpecManager creates a transient Spec manager for the given
+// Spec DB. Transient Specs are written to the highest priority Spec
+// directory of the Spec DB, which is usually DefaultDynamicDir.
+func NewTransientSpecManager(specDB RegistrySpecDB) *TransientSpecManager {
+	return &TransientSpecManager{
+		specDB: specDB,
+		now:    time.Now,
+	}
+}
+
+// WriteSpec writes a transient Spec owned by the given owner. The Spec is
+// named using GenerateNameForTransientSpec with the owner as transient ID.
+// A non-zero ttl sets the time to live of the Spec. The Spec data is not
+// modified, the owner annotations are added to a copy. The version of the
+// copy is raised to the version required for annotations if necessary.
+// On success the name of the written Spec is returned.
+func (m *TransientSpecManager) WriteSpec(raw *cdi.Spec, owner string, ttl time.Duration) (string, error) {
+	if owner == "" {
+		return "", errors.New("invalid transient Spec, empty owner")
+	}
+	name, err := GenerateNameForTransientSpec(raw, owner)
+	if err != nil {
+		return "", err
+	}
+
+	spec := *raw
+	spec.Annotations = make(map[string]string, len(raw.Annotations)+3)
+	for k, v := range raw.Annotations {
+		spec.Annotations[k] = v
+	}
+	spec.Annotations[TransientOwnerAnnotation] = owner
+	spec.Annotations[TransientCreatedAnnotation] = m.now().UTC().Format(time.RFC3339)
+	if ttl > 0 {
+		spec.Annotations[TransientTTLAnnotation] = ttl.String()
+	}
+
+	required, err := MinimumRequiredVersion(&spec)
+	if err != nil {
+		return "", err
+	}
+	if newVersion(required).IsGreaterThan(newVersion(spec.Version)) {
+		spec.Version = required
+	}
+
+	if err := m.specDB.WriteSpec(&spec, name); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// RemoveSpec removes the transient Spec of the given vendor and class
+// owned by the given owner.
+func (m *TransientSpecManager) RemoveSpec(vendor, class, owner string) error {
+	return m.specDB.RemoveSpec(GenerateTransientSpecName(vendor, class, owner))
+}
+
+// GC removes all stale transient Specs. A transient Spec is stale if it
+// has expired, or if alive is not nil and reports its owner gone. Only
+// Specs in the directory transient Specs are written to are considered,
+// Specs in other directories and Specs without owner annotations are
+// never removed. GC returns the paths of
+// the removed Spec files, and any errors encountered. Errors do not stop
+// the collection, Specs with malformed owner metadata are skipped. The
+// detached signatures of removed Specs are removed along with them. If
+// dryRun is true, stale Specs are only reported.
+func (m *TransientSpecManager) GC(alive OwnerAliveFunc, dryRun bool) ([]string, error) {
+	dir, err := m.transientDir()
+	if err != nil {
+		return nil, err
+	}
+
+	m.refresh()
+
+	var (
+		now     = m.now()
+		removed []string
+		errs    []error
+	)
+
+	for _, vendor := range m.specDB.ListVendors() {
+		for _, spec := range m.specDB.GetVendorSpecs(vendor) {
+			if filepath.Dir(spec.GetPath()) != dir {
+				continue
+			}
+			stale, err := m.isStale(spec, now, alive)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("CDI Spec %q: %w", spec.GetPath(), err))
+				continue
+			}
+			if !stale {
+				continue
+			}
+			if !dryRun {
+				err = os.Remove(spec.GetPath())
+				if err != nil && !errors.Is(err, fs.ErrNotExist) {
+					errs = append(errs, fmt.Errorf("failed to remove CDI Spec: %w", err))
+					continue
+				}
+				err = os.Remove(SignatureFile(spec.GetPath()))
+				if err != nil && !errors.Is(err, fs.ErrNotExist) {
+					errs = append(errs, fmt.Errorf("failed to remove CDI Spec signature: %w", err))
+				}
+			}
+			removed = append(removed, spec.GetPath())
+		}
+	}
+
+	if len(removed) > 0 && !dryRun {
+		m.refresh()
+	}
+
+	return removed, multierror.New(errs...)
+}
+
+// transientDir returns the directory transient Specs are written to,
+// the highest priority Spec directory of the Spec DB.
+func (m *TransientSpecManager) transientDir() (string, error) {
+	d, ok := m.specDB.(interface{ GetSpecDirectories() []string })
+	if !ok {
+		return "", errors.New("can't determine transient CDI Spec directory")
+	}
+	dirs := d.GetSpecDirectories()
+	if len(dirs) == 0 {
+		return "", errors.New("no CDI Spec directories")
+	}
+	return filepath.Clean(dirs[len(dirs)-1]), nil
+}
+
+// refresh refreshes the Spec DB if it can be refreshed. Refresh errors
+// are ignored, these are for Specs which failed to load, while transient
+// Specs load fine.
+func (m *TransientSpecManager) refresh() {
+	if r, ok := m.specDB.(interface{ Refresh() error }); ok {
+		_ = r.Refresh()
+	}
+}
+
+// isStale checks if the given Spec is a stale transient Spec.
+func (m *TransientSpecManager) isStale(spec *Spec, now time.Time, alive OwnerAliveFunc) (bool, error) {
+	info, err := GetTransientSpecInfo(spec.Spec)
+	if err != nil || info == nil {
+		return false, err
+	}
+	if info.Expired(now) {
+		return true, nil
+	}
+	if alive == nil {
+		return false, nil
+	}
+	ok, err := alive(info.Owner)
+	if err != nil {
+		return false, fmt.Errorf("failed to check owner %q: %w", info.Owner, err)
+	}
+	return !ok, nil
+}
@@ -0,0 +1,234 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cdi
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	cdi "tags.cncf.io/container-device-interface/specs-go"
+)
+
+func TestTransientSpecManager(t *testing.T) {
+	const (
+		static = `
+cdiVersion: "0.3.0"
+kind: "vendor.com/device"
+devices:
+  - name: "dev0"
+    containerEdits:
+      env:
+        - "FOO=bar"
+`
+		annotated = `
+cdiVersion: "0.6.0"
+kind: "vendor.com/static"
+annotations:
+  cdi.k8s.io/transient-owner: "ctr0"
+  cdi.k8s.io/transient-created: "2024-01-01T00:00:00Z"
+  cdi.k8s.io/transient-ttl: "1m"
+devices:
+  - name: "dev0"
+    containerEdits:
+      env:
+        - "FOO=bar"
+`
+	)
+
+	dir, err := createSpecDirs(t,
+		map[string]string{
+			"vendor.yaml":    static,
+			"annotated.yaml": annotated,
+		},
+		nil,
+	)
+	require.NoError(t, err)
+
+	cache, err := NewCache(
+		WithSpecDirs(
+			filepath.Join(dir, "etc"),
+			filepath.Join(dir, "run"),
+		),
+		WithAutoRefresh(false),
+	)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := NewTransientSpecManager(cache)
+	m.now = func() time.Time { return now }
+
+	transient := func(name string) *cdi.Spec {
+		return &cdi.Spec{
+			Version: "0.3.0",
+			Kind:    "vendor.com/transient",
+			Devices: []cdi.Device{
+				{
+					Name: name,
+					ContainerEdits: cdi.ContainerEdits{
+						Env: []string{"DEVICE=" + name},
+					},
+				},
+			},
+		}
+	}
+
+	raw := transient("ctr1")
+	name, err := m.WriteSpec(raw, "ctr1", time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, "vendor.com-transient_ctr1", name)
+	require.Nil(t, raw.Annotations, "Spec data modified")
+
+	_, err = m.WriteSpec(transient("ctr2"), "ctr2", 0)
+	require.NoError(t, err)
+	_, err = m.WriteSpec(transient("ctr3"), "ctr3", 0)
+	require.NoError(t, err)
+	_, err = m.WriteSpec(transient("ctr4"), "", 0)
+	require.Error(t, err)
+
+	require.NoError(t, cache.Refresh())
+	specs := cache.GetVendorSpecs("vendor.com")
+	require.Len(t, specs, 5)
+	for _, spec := range specs {
+		info, err := GetTransientSpecInfo(spec.Spec)
+		require.NoError(t, err)
+		if spec.Kind == "vendor.com/device" {
+			require.Nil(t, info)
+			continue
+		}
+		if spec.Kind == "vendor.com/static" {
+			continue
+		}
+		require.NotNil(t, info)
+		require.Equal(t, "0.6.0", spec.Version)
+		require.Equal(t, now, info.Created)
+		if info.Owner == "ctr1" {
+			require.Equal(t, time.Hour, info.TTL)
+		} else {
+			require.Equal(t, time.Duration(0), info.TTL)
+		}
+	}
+
+	transientPath := func(owner string) string {
+		return filepath.Join(dir, "run", "vendor.com-transient_"+owner+".yaml")
+	}
+
+	// nothing expired, all owners alive
+	removed, err := m.GC(func(string) (bool, error) { return true, nil }, false)
+	require.NoError(t, err)
+	require.Empty(t, removed)
+
+	// ctr1 expired, ctr2 is gone, checking ctr3 fails
+	now = now.Add(2 * time.Hour)
+	alive := func(owner string) (bool, error) {
+		switch owner {
+		case "ctr2":
+			return false, nil
+		case "ctr3":
+			return false, errors.New("owner lookup failed")
+		}
+		return true, nil
+	}
+
+	require.NoError(t, os.WriteFile(SignatureFile(transientPath("ctr1")), []byte("signature\n"), 0o644))
+
+	removed, err = m.GC(alive, true)
+	require.Error(t, err)
+	require.ElementsMatch(t, []string{transientPath("ctr1"), transientPath("ctr2")}, removed)
+	require.FileExists(t, transientPath("ctr1"))
+	require.FileExists(t, SignatureFile(transientPath("ctr1")))
+
+	removed, err = m.GC(alive, false)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "owner lookup failed")
+	require.ElementsMatch(t, []string{transientPath("ctr1"), transientPath("ctr2")}, removed)
+	for _, path := range []string{transientPath("ctr1"), SignatureFile(transientPath("ctr1")), transientPath("ctr2")} {
+		_, err := os.Stat(path)
+		require.True(t, os.IsNotExist(err))
+	}
+	require.FileExists(t, transientPath("ctr3"))
+	require.FileExists(t, filepath.Join(dir, "etc", "vendor.yaml"))
+	// expired and owned, but not in the transient Spec directory
+	require.FileExists(t, filepath.Join(dir, "etc", "annotated.yaml"))
+	require.Len(t, cache.GetVendorSpecs("vendor.com"), 3)
+
+	require.NoError(t, m.RemoveSpec("vendor.com", "transient", "ctr3"))
+	_, err = os.Stat(transientPath("ctr3"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestGetTransientSpecInfo(t *testing.T) {
+	type testCase struct {
+		name        string
+		annotations map[string]string
+		info        *TransientSpecInfo
+		invalid     bool
+	}
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []*testCase{
+		{
+			name: "not transient",
+		},
+		{
+			name: "owner only",
+			annotations: map[string]string{
+				TransientOwnerAnnotation: "ctr1",
+			},
+			info: &TransientSpecInfo{Owner: "ctr1"},
+		},
+		{
+			name: "owner, creation time and TTL",
+			annotations: map[string]string{
+				TransientOwnerAnnotation:   "ctr1",
+				TransientCreatedAnnotation: created.Format(time.RFC3339),
+				TransientTTLAnnotation:     "1h",
+			},
+			info: &TransientSpecInfo{Owner: "ctr1", Created: created, TTL: time.Hour},
+		},
+		{
+			name: "TTL without creation time",
+			annotations: map[string]string{
+				TransientOwnerAnnotation: "ctr1",
+				TransientTTLAnnotation:   "1h",
+			},
+			invalid: true,
+		},
+		{
+			name: "invalid creation time",
+			annotations: map[string]string{
+				TransientOwnerAnnotation:   "ctr1",
+				TransientCreatedAnnotation: "yesterday",
+			},
+			invalid: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			info, err := GetTransientSpecInfo(&cdi.Spec{Annotations: tc.annotations})
+			if tc.invalid {
+				require.Error(t, err)
+				require.Nil(t, info)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.info, info)
+		})
+	}
+}
@@ -17,9 +17,11 @@
 package cdi
 
 import (
+	"fmt"
 	"strings"
 
 	"golang.org/x/mod/semver"
+	"sigs.k8s.io/yaml"
 
 	"tags.cncf.io/container-device-interface/pkg/parser"
 	cdi "tags.cncf.io/container-device-interface/specs-go"
@@ -86,7 +88,10 @@ func (v version) IsLatest() bool {
 	return v == vCurrent
 }
 
-type requiredFunc func(*cdi.Spec) bool
+// requiredFunc returns the uses of features of the given spec which
+// require a given version. A spec not using any such features results
+// in no uses. The version of the returned uses is not set.
+type requiredFunc func(*cdi.Spec) []FeatureUse
 
 type requiredVersionMap map[version]requiredFunc
 
@@ -106,7 +111,7 @@ func (r requiredVersionMap) requiredVersion(spec *cdi.Spec) version {
 		if isRequired == nil {
 			continue
 		}
-		if isRequired(spec) && v.IsGreaterThan(minVersion) {
+		if v.IsGreaterThan(minVersion) && len(isRequired(spec)) > 0 {
 			minVersion = v
 		}
 		// If we have already detected the latest version then no later version could be detected
@@ -118,17 +123,22 @@ func (r requiredVersionMap) requiredVersion(spec *cdi.Spec) version {
 	return minVersion
 }
 
-// requiresV060 returns true if the spec uses v0.6.0 features
-func requiresV060(spec *cdi.Spec) bool {
+// requiresV060 returns the uses of v0.6.0 features in the spec
+func requiresV060(spec *cdi.Spec) []FeatureUse {
+	var uses []FeatureUse
+
 	// The v0.6.0 spec allows annotations to be specified at a spec level
-	for range spec.Annotations {
-		return true
+	if len(spec.Annotations) > 0 {
+		uses = append(uses, FeatureUse{Feature: FeatureSpecAnnotations, Use: "Spec annotations"})
 	}
 
 	// The v0.6.0 spec allows annotations to be specified at a device level
 	for _, d := range spec.Devices {
-		for range d.Annotations {
-			return true
+		if len(d.Annotations) > 0 {
+			uses = append(uses, FeatureUse{
+				Feature: FeatureDeviceAnnotations,
+				Use:     fmt.Sprintf("device %q: annotations", d.Name),
+			})
 		}
 	}
 
@@ -136,53 +146,136 @@ func requiresV060(spec *cdi.Spec) bool {
 	vendor, class := parser.ParseQualifier(spec.Kind)
 	if vendor != "" {
 		if strings.ContainsRune(class, '.') {
-			return true
+			uses = append(uses, FeatureUse{
+				Feature: FeatureClassNameDots,
+				Use:     fmt.Sprintf("dot in class name %q", class),
+			})
 		}
 	}
 
-	return false
+	return uses
 }
 
-// requiresV050 returns true if the spec uses v0.5.0 features
-func requiresV050(spec *cdi.Spec) bool {
-	var edits []*cdi.ContainerEdits
+// requiresV050 returns the uses of v0.5.0 features in the spec
+func requiresV050(spec *cdi.Spec) []FeatureUse {
+	var uses []FeatureUse
 
 	for _, d := range spec.Devices {
 		// The v0.5.0 spec allowed device names to start with a digit instead of requiring a letter
 		if len(d.Name) > 0 && !parser.IsLetter(rune(d.Name[0])) {
-			return true
+			uses = append(uses, FeatureUse{
+				Feature: FeatureDeviceNameNonLetterStart,
+				Use:     fmt.Sprintf("device %q: name not starting with a letter", d.Name),
+			})
 		}
-		edits = append(edits, &d.ContainerEdits)
 	}
 
-	edits = append(edits, &spec.ContainerEdits)
-	for _, e := range edits {
-		for _, dn := range e.DeviceNodes {
+	for _, e := range specEdits(spec) {
+		for _, dn := range e.edits.DeviceNodes {
 			// The HostPath field was added in v0.5.0
 			if dn.HostPath != "" {
-				return true
+				uses = append(uses, FeatureUse{
+					Feature: FeatureDeviceNodeHostPath,
+					Use:     fmt.Sprintf("%s: device node %q: hostPath", e.owner, dn.Path),
+				})
 			}
 		}
 	}
-	return false
-}
 
-// requiresV040 returns true if the spec uses v0.4.0 features
-func requiresV040(spec *cdi.Spec) bool {
-	var edits []*cdi.ContainerEdits
+	return uses
+}
 
-	for _, d := range spec.Devices {
-		edits = append(edits, &d.ContainerEdits)
-	}
+// requiresV040 returns the uses of v0.4.0 features in the spec
+func requiresV040(spec *cdi.Spec) []FeatureUse {
+	var uses []FeatureUse
 
-	edits = append(edits, &spec.ContainerEdits)
-	for _, e := range edits {
-		for _, m := range e.Mounts {
+	for _, e := range specEdits(spec) {
+		for _, m := range e.edits.Mounts {
 			// The Type field was added in v0.4.0
 			if m.Type != "" {
-				return true
+				uses = append(uses, FeatureUse{
+					Feature: FeatureMountType,
+					Use:     fmt.Sprintf("%s: mount %q: type", e.owner, m.ContainerPath),
+				})
 			}
 		}
 	}
-	return false
+
+	return uses
+}
+
+// ownedEdits are container edits with a description of their owner.
+type ownedEdits struct {
+	owner string
+	edits *cdi.ContainerEdits
+}
+
+// specEdits returns all container edits of the spec.
+func specEdits(spec *cdi.Spec) []ownedEdits {
+	var edits []ownedEdits
+
+	for i := range spec.Devices {
+		d := &spec.Devices[i]
+		edits = append(edits, ownedEdits{fmt.Sprintf("device %q", d.Name), &d.ContainerEdits})
+	}
+	edits = append(edits, ownedEdits{"Spec", &spec.ContainerEdits})
+
+	return edits
+}
+
+// SpecVersionError is the error for a Spec file with a version later
+// than the maximum version configured using WithMaxSpecVersion.
+type SpecVersionError struct {
+	// Path of the Spec file.
+	Path string
+	// Version of the Spec file.
+	Version string
+	// MaxVersion is the maximum supported version.
+	MaxVersion string
+}
+
+// Error returns the error message for the unsupported Spec version.
+func (e *SpecVersionError) Error() string {
+	return fmt.Sprintf("CDI Spec %q version %s is later than the maximum supported version %s",
+		e.Path, e.Version, e.MaxVersion)
+}
+
+// WithMaxSpecVersion returns an option to set the maximum Spec version
+// to load. Spec files with a later version are skipped without parsing
+// them, and a *SpecVersionError is recorded for them as a Cache error.
+// An empty version, the default, loads Specs of all valid versions.
+func WithMaxSpecVersion(v string) Option {
+	return func(c *Cache) error {
+		if v != "" && !validSpecVersions.isValidVersion(v) {
+			return fmt.Errorf("invalid maximum CDI Spec version %q", v)
+		}
+		c.maxVersion = strings.TrimPrefix(v, "v")
+		return nil
+	}
+}
+
+// checkMaxVersion checks the version of Spec data against the given
+// maximum version. Only the version is looked at, Spec data which fails
+// to parse is left for the full parser to report.
+func checkMaxVersion(path string, data []byte, maxVersion string) error {
+	if maxVersion == "" {
+		return nil
+	}
+
+	var header struct {
+		Version string `json:"cdiVersion"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil || header.Version == "" {
+		return nil
+	}
+
+	if newVersion(header.Version).IsGreaterThan(newVersion(maxVersion)) {
+		return &SpecVersionError{
+			Path:       path,
+			Version:    header.Version,
+			MaxVersion: maxVersion,
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,65 @@
+//go:build ignore
+// +build ignore
+
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// gen generates schema.json and defs.json from the Go types of the Spec,
+// both for all versions and for each released version.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"tags.cncf.io/container-device-interface/schema"
+)
+
+func main() {
+	schemaData, defsData, err := schema.Generate()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to generate schema: %v\n", err)
+		os.Exit(1)
+	}
+	write(".", schemaData, defsData)
+
+	for _, version := range schema.Versions() {
+		schemaData, defsData, err := schema.GenerateVersion(version)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to generate schema for version %s: %v\n", version, err)
+			os.Exit(1)
+		}
+		write(schema.VersionDir(version), schemaData, defsData)
+	}
+}
+
+func write(dir string, schemaData, defsData []byte) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create %s: %v\n", dir, err)
+		os.Exit(1)
+	}
+	for file, data := range map[string][]byte{
+		schema.SchemaFile: schemaData,
+		schema.DefsFile:   defsData,
+	} {
+		file = filepath.Join(dir, file)
+		if err := os.WriteFile(file, data, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", file, err)
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,408 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"golang.org/x/mod/semver"
+	cdi "tags.cncf.io/container-device-interface/specs-go"
+)
+
+//go:generate go run gen.go
+
+const (
+	// SchemaFile is the name of the generated schema file.
+	SchemaFile = "schema.json"
+	// DefsFile is the name of the generated file of schema definitions.
+	DefsFile = "defs.json"
+)
+
+var (
+	// descriptions of types and fields, by type name or type.field name
+	descriptions = map[string]string{
+		"Spec":            "Configuration Schema for the Container Device Interface",
+		"Spec.cdiVersion": "The version of the Container Device Interface Specification that the document complies with",
+		"Spec.kind":       "The kind of the device usually of the form 'vendor.com/device'",
+		"Device.name":     "The name of the device",
+	}
+	// definitions for fields, where the schema is stricter than the Go type
+	fieldDefinitions = map[string]string{
+		"Hook.timeout": "uint32",
+	}
+	// names of definitions of types, other than the name of the type
+	definitionNames = map[reflect.Type]string{
+		reflect.TypeOf(cdi.ContainerEdits{}): "containerEdits",
+	}
+	// inlined types are defined in place instead of as definitions
+	inlined = map[reflect.Type]bool{
+		reflect.TypeOf(cdi.Device{}): true,
+	}
+	// versions introducing fields, fields not listed here are in all versions,
+	// these must agree with the feature table of the cdi package (tested)
+	fieldVersions = map[string]string{
+		"Mount.type":          "0.4.0",
+		"DeviceNode.hostPath": "0.5.0",
+		"Spec.annotations":    "0.6.0",
+		"Device.annotations":  "0.6.0",
+	}
+	// patterns of names, by the versions introducing them, only enforced by
+	// the schemas of individual versions, these must agree with the feature
+	// table of the cdi package (tested)
+	namePatterns = map[string][]versionPattern{
+		"Spec.kind": {
+			{"0.3.0", "^" + vendorPattern + "/" + classPattern + "$"},
+			{"0.6.0", "^" + vendorPattern + "/" + vendorPattern + "$"},
+		},
+		"Device.name": {
+			{"0.3.0", "^[a-zA-Z]([a-zA-Z0-9_.:-]*[a-zA-Z0-9])?$"},
+			{"0.5.0", "^[a-zA-Z0-9]([a-zA-Z0-9_.:-]*[a-zA-Z0-9])?$"},
+		},
+	}
+	// released Spec versions with a builtin schema
+	specVersions = []string{
+		"0.3.0",
+		"0.4.0",
+		"0.5.0",
+		"0.6.0",
+	}
+)
+
+const (
+	// vendorPattern is the pattern of vendor names, and of class names
+	// since version 0.6.0
+	vendorPattern = "[a-zA-Z]([a-zA-Z0-9_.-]*[a-zA-Z0-9])?"
+	// classPattern is the pattern of class names before version 0.6.0
+	classPattern = "[a-zA-Z]([a-zA-Z0-9_-]*[a-zA-Z0-9])?"
+)
+
+// versionPattern is a pattern introduced by a Spec version.
+type versionPattern struct {
+	version string
+	pattern string
+}
+
+// Versions returns the Spec versions with a builtin schema.
+func Versions() []string {
+	return append([]string(nil), specVersions...)
+}
+
+// Generate generates the schema and its definitions from the Go types
+// of the Spec. The committed schema.json and defs.json are generated by
+// 'go generate'. Properties are derived from the JSON struct tags of the
+// types, with properties not tagged 'omitempty' being required.
+func Generate() (schema []byte, defs []byte, err error) {
+	return generate("")
+}
+
+// GenerateVersion generates the schema and its definitions for the given
+// Spec version. Unlike the schema generated by Generate, it only allows
+// the properties defined by the version, and it enforces the rules of the
+// version for the kind and device names. The committed per-version files
+// are stored in a directory named after the version, for instance v0.5.0.
+func GenerateVersion(version string) (schema []byte, defs []byte, err error) {
+	version = strings.TrimPrefix(version, "v")
+	for _, v := range specVersions {
+		if v == version {
+			return generate(version)
+		}
+	}
+	return nil, nil, fmt.Errorf("no schema for Spec version %q", version)
+}
+
+// VersionDir returns the directory of the schema for a Spec version.
+func VersionDir(version string) string {
+	return "v" + strings.TrimPrefix(version, "v")
+}
+
+// generate generates the schema for the given version, or for all
+// versions if version is empty.
+func generate(version string) (schema []byte, defs []byte, err error) {
+	g := &generator{
+		version: version,
+		defs: object{
+			{"uint32", object{
+				{"type", "integer"},
+				{"minimum", 0},
+				{"maximum", uint32(1<<32 - 1)},
+			}},
+			{"int64", object{
+				{"type", "integer"},
+				{"minimum", int64(-1 << 63)},
+				{"maximum", int64(1<<63 - 1)},
+			}},
+			{"ArrayOfStrings", object{
+				{"type", "array"},
+				{"items", object{{"type", "string"}}},
+			}},
+			{"FilePath", object{
+				{"type", "string"},
+			}},
+			{"Env", ref("ArrayOfStrings")},
+			{"mapStringString", object{
+				{"type", "object"},
+				{"patternProperties", object{
+					{".{1,}", object{{"type", "string"}}},
+				}},
+			}},
+		},
+		defined: map[reflect.Type]bool{},
+	}
+
+	spec, err := g.structType(reflect.TypeOf(cdi.Spec{}), defsRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	spec = append(object{
+		{"description", descriptions["Spec"]},
+		{"$schema", "http://json-schema.org/draft-07/schema#"},
+	}, spec...)
+
+	g.defs = append(g.defs, member{"annotations", ref("mapStringString")})
+
+	if schema, err = encode(spec); err != nil {
+		return nil, nil, err
+	}
+	defs, err = encode(object{
+		{"description", "Definitions used throughout the Container Device Interface Specification"},
+		{"definitions", g.defs},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return schema, defs, nil
+}
+
+// generator generates the schema for Go types.
+type generator struct {
+	version string
+	defs    object
+	defined map[reflect.Type]bool
+}
+
+// refFunc returns a reference to a named definition.
+type refFunc func(name string) object
+
+// ref returns a reference to a definition within the same file.
+func ref(name string) object {
+	return object{{"$ref", "#/definitions/" + name}}
+}
+
+// defsRef returns a reference to a definition in the definitions file.
+func defsRef(name string) object {
+	return object{{"$ref", DefsFile + "#/definitions/" + name}}
+}
+
+// structType generates the schema of a struct type.
+func (g *generator) structType(t reflect.Type, refTo refFunc) (object, error) {
+	var (
+		properties object
+		required   []string
+	)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			return nil, fmt.Errorf("field %s.%s has no JSON name", t.Name(), f.Name)
+		}
+
+		field := t.Name() + "." + name
+		if !g.hasField(field) {
+			continue
+		}
+		property, err := g.field(field, name, f.Type, refTo)
+		if err != nil {
+			return nil, err
+		}
+		if d, ok := descriptions[field]; ok {
+			property = append(object{{"description", d}}, property...)
+		}
+		if p := g.pattern(field); p != "" {
+			property = append(property, member{"pattern", p})
+		}
+		properties = append(properties, member{name, property})
+
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	s := object{
+		{"type", "object"},
+		{"properties", properties},
+	}
+	if len(required) > 0 {
+		s = append(s, member{"required", required})
+	}
+	if g.version != "" {
+		s = append(s, member{"additionalProperties", false})
+	}
+
+	return s, nil
+}
+
+// hasField checks if the version being generated has the given field.
+func (g *generator) hasField(field string) bool {
+	if g.version == "" {
+		return true
+	}
+	v, ok := fieldVersions[field]
+	return !ok || semver.Compare("v"+v, "v"+g.version) <= 0
+}
+
+// pattern returns the pattern of a field for the version being generated.
+func (g *generator) pattern(field string) string {
+	if g.version == "" {
+		return ""
+	}
+	pattern := ""
+	for _, p := range namePatterns[field] {
+		if semver.Compare("v"+p.version, "v"+g.version) <= 0 {
+			pattern = p.pattern
+		}
+	}
+	return pattern
+}
+
+// field generates the schema of a struct field.
+func (g *generator) field(field, name string, t reflect.Type, refTo refFunc) (object, error) {
+	if def, ok := fieldDefinitions[field]; ok {
+		return refTo(def), nil
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		if name == "path" || strings.HasSuffix(name, "Path") {
+			return refTo("FilePath"), nil
+		}
+		return object{{"type", "string"}}, nil
+	case reflect.Int64:
+		return refTo("int64"), nil
+	case reflect.Uint32:
+		return refTo("uint32"), nil
+	case reflect.Int:
+		return object{{"type", "integer"}}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String || t.Elem().Kind() != reflect.String {
+			break
+		}
+		if name == "annotations" {
+			return refTo("annotations"), nil
+		}
+		return refTo("mapStringString"), nil
+	case reflect.Slice:
+		elem := t.Elem()
+		if elem.Kind() == reflect.String {
+			if name == "env" {
+				return refTo("Env"), nil
+			}
+			return refTo("ArrayOfStrings"), nil
+		}
+		items, err := g.field(field, "", elem, refTo)
+		if err != nil {
+			return nil, err
+		}
+		return object{
+			{"type", "array"},
+			{"items", items},
+		}, nil
+	case reflect.Struct:
+		if inlined[t] {
+			return g.structType(t, refTo)
+		}
+		return g.definition(t, refTo)
+	}
+
+	return nil, fmt.Errorf("field %s: unsupported type %s", field, t)
+}
+
+// definition returns a reference to the definition of a struct type,
+// generating the definition if necessary.
+func (g *generator) definition(t reflect.Type, refTo refFunc) (object, error) {
+	name, ok := definitionNames[t]
+	if !ok {
+		name = t.Name()
+	}
+
+	if !g.defined[t] {
+		g.defined[t] = true
+		def, err := g.structType(t, ref)
+		if err != nil {
+			return nil, err
+		}
+		g.defs = append(g.defs, member{name, def})
+	}
+
+	return refTo(name), nil
+}
+
+// object is a JSON object with ordered members.
+type object []member
+
+// member is a member of a JSON object.
+type member struct {
+	name  string
+	value any
+}
+
+// MarshalJSON encodes the object with its members in order.
+func (o object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+
+	b.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, err := json.Marshal(m.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %q: %w", m.name, err)
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+
+	return b.Bytes(), nil
+}
+
+// encode encodes an object as indented JSON.
+func encode(o object) ([]byte, error) {
+	data, err := json.MarshalIndent(o, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,63 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package schema_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"tags.cncf.io/container-device-interface/schema"
+)
+
+func TestGeneratedSchema(t *testing.T) {
+	schemaData, defsData, err := schema.Generate()
+	require.NoError(t, err)
+
+	for file, generated := range map[string][]byte{
+		schema.SchemaFile: schemaData,
+		schema.DefsFile:   defsData,
+	} {
+		committed, err := os.ReadFile(file)
+		require.NoError(t, err)
+		require.Equal(t, string(generated), string(committed),
+			"%s does not match the Go Spec types, run 'go generate ./schema'", file)
+	}
+}
+
+func TestGeneratedVersionSchemas(t *testing.T) {
+	for _, version := range schema.Versions() {
+		schemaData, defsData, err := schema.GenerateVersion(version)
+		require.NoError(t, err)
+
+		for file, generated := range map[string][]byte{
+			schema.SchemaFile: schemaData,
+			schema.DefsFile:   defsData,
+		} {
+			file = filepath.Join(schema.VersionDir(version), file)
+			committed, err := os.ReadFile(file)
+			require.NoError(t, err)
+			require.Equal(t, string(generated), string(committed),
+				"%s does not match the Go Spec types, run 'go generate ./schema'", file)
+		}
+	}
+
+	_, _, err := schema.GenerateVersion("0.2.0")
+	require.Error(t, err)
+}
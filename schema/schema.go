@@ -32,6 +32,7 @@ import (
 	schema "github.com/xeipuuv/gojsonschema"
 	"tags.cncf.io/container-device-interface/internal/multierror"
 	"tags.cncf.io/container-device-interface/internal/validation"
+	cdi "tags.cncf.io/container-device-interface/specs-go"
 )
 
 const (
@@ -39,6 +40,9 @@ const (
 	BuiltinSchemaName = "builtin"
 	// NoneSchemaName names the NOP-schema for Load()/Set().
 	NoneSchemaName = "none"
+	// BuiltinVersionPrefix prefixes a Spec version to name the builtin
+	// schema of that version for Load(), for instance "builtin:v0.5.0".
+	BuiltinVersionPrefix = BuiltinSchemaName + ":"
 	// builtinSchemaFile is the builtin schema URI in our embedded FS.
 	builtinSchemaFile = "file:///schema.json"
 )
@@ -46,6 +50,9 @@ const (
 // Schema is a JSON validation schema.
 type Schema struct {
 	schema *schema.Schema
+	// versions are the schemas of Spec versions, by version. Documents
+	// with one of these versions are validated against its schema.
+	versions map[string]*schema.Schema
 }
 
 // Error wraps a JSON validation result.
@@ -64,28 +71,55 @@ func Get() *Schema {
 }
 
 // BuiltinSchema returns the builtin schema if we have a valid one. Otherwise
-// it falls back to NopSchema().
+// it falls back to NopSchema(). Documents are validated against the builtin
+// schema of their cdiVersion, or if there is none, against a schema which
+// allows the properties of all versions.
 func BuiltinSchema() *Schema {
 	if builtin != nil {
 		return builtin
 	}
 
-	s, err := schema.NewSchema(
-		schema.NewReferenceLoaderFileSystem(
-			builtinSchemaFile,
-			http.FS(builtinFS),
-		),
-	)
-
-	if err == nil {
-		builtin = &Schema{schema: s}
-	} else {
+	s, err := loadBuiltin(builtinSchemaFile)
+	if err != nil {
 		builtin = NopSchema()
+		return builtin
+	}
+
+	builtin = &Schema{
+		schema:   s,
+		versions: map[string]*schema.Schema{},
+	}
+	for _, version := range specVersions {
+		s, err := loadBuiltin(builtinVersionFile(version))
+		if err != nil {
+			builtin = NopSchema()
+			return builtin
+		}
+		builtin.versions[version] = s
 	}
 
 	return builtin
 }
 
+// BuiltinVersionSchema returns the builtin schema of the given Spec
+// version. All documents are validated against this schema, regardless
+// of their cdiVersion.
+func BuiltinVersionSchema(version string) (*Schema, error) {
+	version = strings.TrimPrefix(version, "v")
+	for _, v := range specVersions {
+		if v != version {
+			continue
+		}
+		s, err := loadBuiltin(builtinVersionFile(version))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load JSON schema for version %s: %w", version, err)
+		}
+		return &Schema{schema: s}, nil
+	}
+	return nil, fmt.Errorf("no builtin JSON schema for version %q, known versions are %s",
+		version, strings.Join(specVersions, ", "))
+}
+
 // NopSchema returns an validating JSON Schema that does no real validation.
 func NopSchema() *Schema {
 	return &Schema{}
@@ -129,6 +163,8 @@ func Load(source string) (*Schema, error) {
 	switch {
 	case source == BuiltinSchemaName:
 		return BuiltinSchema(), nil
+	case strings.HasPrefix(source, BuiltinVersionPrefix):
+		return BuiltinVersionSchema(strings.TrimPrefix(source, BuiltinVersionPrefix))
 	case source == NoneSchemaName, source == "":
 		return NopSchema(), nil
 	case strings.HasPrefix(source, "file://"):
@@ -157,12 +193,11 @@ func Load(source string) (*Schema, error) {
 
 // ReadAndValidate all data from the given reader, using the schema for validation.
 func (s *Schema) ReadAndValidate(r io.Reader) ([]byte, error) {
-	loader, reader := schema.NewReaderLoader(r)
-	data, err := ioutil.ReadAll(reader)
+	data, err := ioutil.ReadAll(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read data for validation: %w", err)
 	}
-	return data, s.validate(loader)
+	return data, s.validate(peekVersion(data), schema.NewBytesLoader(data))
 }
 
 // Validate validates the data read from an io.Reader against the schema.
@@ -189,7 +224,7 @@ func (s *Schema) ValidateData(data []byte) error {
 		}
 	}
 
-	if err := s.validate(schema.NewBytesLoader(data)); err != nil {
+	if err := s.validate(peekVersion(data), schema.NewBytesLoader(data)); err != nil {
 		return err
 	}
 
@@ -198,30 +233,43 @@ func (s *Schema) ValidateData(data []byte) error {
 
 // ValidateFile validates the given JSON file against the schema.
 func (s *Schema) ValidateFile(path string) error {
-	if filepath.Ext(path) == ".json" {
-		return s.validate(schema.NewReferenceLoader("file://" + path))
-	}
-
 	data, err := ioutil.ReadFile(path)
 	if err != nil {
 		return err
 	}
+
+	if filepath.Ext(path) == ".json" {
+		return s.validate(peekVersion(data), schema.NewBytesLoader(data))
+	}
+
 	return s.ValidateData(data)
 }
 
 // ValidateType validates a go object against the schema.
 func (s *Schema) ValidateType(obj interface{}) error {
-	l := schema.NewGoLoader(obj)
-	return s.validate(l)
+	var version string
+	if len(s.getVersions()) > 0 {
+		if spec, ok := obj.(*cdi.Spec); ok {
+			version = spec.Version
+		} else if data, err := json.Marshal(obj); err == nil {
+			version = peekVersion(data)
+		}
+	}
+	return s.validate(version, schema.NewGoLoader(obj))
 }
 
-// Validate the (to be) loaded doc against the schema.
-func (s *Schema) validate(doc schema.JSONLoader) error {
+// Validate the (to be) loaded doc of the given version against the schema.
+func (s *Schema) validate(version string, doc schema.JSONLoader) error {
 	if s == nil || s.schema == nil {
 		return nil
 	}
 
-	docErr, jsonErr := s.schema.Validate(doc)
+	scm := s.schema
+	if v, ok := s.versions[strings.TrimPrefix(version, "v")]; ok {
+		scm = v
+	}
+
+	docErr, jsonErr := scm.Validate(doc)
 	if jsonErr != nil {
 		return fmt.Errorf("failed to load JSON data for validation: %w", jsonErr)
 	}
@@ -232,6 +280,41 @@ func (s *Schema) validate(doc schema.JSONLoader) error {
 	return &Error{Result: docErr}
 }
 
+// getVersions returns the schemas of Spec versions.
+func (s *Schema) getVersions() map[string]*schema.Schema {
+	if s == nil {
+		return nil
+	}
+	return s.versions
+}
+
+// peekVersion returns the cdiVersion of a JSON document, or an empty
+// string if it has none.
+func peekVersion(data []byte) string {
+	var doc struct {
+		Version string `json:"cdiVersion"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return ""
+	}
+	return doc.Version
+}
+
+// loadBuiltin loads a schema from our embedded FS.
+func loadBuiltin(uri string) (*schema.Schema, error) {
+	return schema.NewSchema(
+		schema.NewReferenceLoaderFileSystem(
+			uri,
+			http.FS(builtinFS),
+		),
+	)
+}
+
+// builtinVersionFile returns the URI of the builtin schema of a version.
+func builtinVersionFile(version string) string {
+	return "file:///" + VersionDir(version) + "/" + SchemaFile
+}
+
 type schemaContents map[string]interface{}
 
 func asSchemaContents(i interface{}) (schemaContents, error) {
@@ -347,5 +430,5 @@ var (
 	current = BuiltinSchema()
 )
 
-//go:embed *.json
+//go:embed *.json v*/*.json
 var builtinFS embed.FS
@@ -18,6 +18,8 @@ package schema_test
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
 	"io/fs"
 	"io/ioutil"
@@ -29,6 +31,7 @@ import (
 
 	"tags.cncf.io/container-device-interface/pkg/cdi"
 	"tags.cncf.io/container-device-interface/schema"
+	specs "tags.cncf.io/container-device-interface/specs-go"
 )
 
 var (
@@ -375,3 +378,100 @@ func verifyResult(t *testing.T, s *schema.Schema, err error, shouldLoad, isValid
 		require.NoError(t, err)
 	}
 }
+
+func TestVersionSchemas(t *testing.T) {
+	type testCase struct {
+		name    string
+		spec    string
+		invalid []string
+	}
+	for _, tc := range []*testCase{
+		{
+			name: "mount type",
+			spec: `{"cdiVersion": "%s", "kind": "vendor.com/device", "devices": [{"name": "dev0",
+				"containerEdits": {"mounts": [{"hostPath": "/a", "containerPath": "/a", "type": "bind"}]}}]}`,
+			invalid: []string{"0.3.0"},
+		},
+		{
+			name: "device node hostPath",
+			spec: `{"cdiVersion": "%s", "kind": "vendor.com/device", "devices": [{"name": "dev0",
+				"containerEdits": {"deviceNodes": [{"path": "/dev/a", "hostPath": "/dev/b"}]}}]}`,
+			invalid: []string{"0.3.0", "0.4.0"},
+		},
+		{
+			name: "Spec annotations",
+			spec: `{"cdiVersion": "%s", "kind": "vendor.com/device", "annotations": {"a": "b"},
+				"devices": [{"name": "dev0", "containerEdits": {"env": ["FOO=bar"]}}]}`,
+			invalid: []string{"0.3.0", "0.4.0", "0.5.0"},
+		},
+		{
+			name: "device annotations",
+			spec: `{"cdiVersion": "%s", "kind": "vendor.com/device", "devices": [{"name": "dev0",
+				"annotations": {"a": "b"}, "containerEdits": {"env": ["FOO=bar"]}}]}`,
+			invalid: []string{"0.3.0", "0.4.0", "0.5.0"},
+		},
+		{
+			name: "device name not starting with a letter",
+			spec: `{"cdiVersion": "%s", "kind": "vendor.com/device", "devices": [{"name": "0",
+				"containerEdits": {"env": ["FOO=bar"]}}]}`,
+			invalid: []string{"0.3.0", "0.4.0"},
+		},
+		{
+			name: "dot in class name",
+			spec: `{"cdiVersion": "%s", "kind": "vendor.com/device.class", "devices": [{"name": "dev0",
+				"containerEdits": {"env": ["FOO=bar"]}}]}`,
+			invalid: []string{"0.3.0", "0.4.0", "0.5.0"},
+		},
+		{
+			name: "invalid device name",
+			spec: `{"cdiVersion": "%s", "kind": "vendor.com/device", "devices": [{"name": "dev0-",
+				"containerEdits": {"env": ["FOO=bar"]}}]}`,
+			invalid: []string{"0.3.0", "0.4.0", "0.5.0", "0.6.0"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			invalid := map[string]bool{}
+			for _, v := range tc.invalid {
+				invalid[v] = true
+			}
+
+			builtin := schema.BuiltinSchema()
+			for _, version := range schema.Versions() {
+				data := []byte(fmt.Sprintf(tc.spec, version))
+
+				// the builtin schema picks the schema by cdiVersion
+				err := builtin.ValidateData(data)
+				if invalid[version] {
+					require.Error(t, err, "version %s", version)
+				} else {
+					require.NoError(t, err, "version %s", version)
+				}
+
+				var raw specs.Spec
+				require.NoError(t, json.Unmarshal(data, &raw))
+				err = builtin.ValidateType(&raw)
+				if invalid[version] {
+					require.Error(t, err, "version %s", version)
+				} else {
+					require.NoError(t, err, "version %s", version)
+				}
+
+				// a version schema ignores cdiVersion
+				scm, err := schema.Load(schema.BuiltinVersionPrefix + "v" + version)
+				require.NoError(t, err)
+				err = scm.ValidateData([]byte(fmt.Sprintf(tc.spec, specs.CurrentVersion)))
+				if invalid[version] {
+					require.Error(t, err, "version %s", version)
+				} else {
+					require.NoError(t, err, "version %s", version)
+				}
+			}
+
+			// unknown versions are validated against the schema of all versions
+			require.NoError(t, builtin.ValidateData([]byte(fmt.Sprintf(tc.spec, "0.2.0"))))
+		})
+	}
+
+	_, err := schema.Load(schema.BuiltinVersionPrefix + "0.2.0")
+	require.Error(t, err)
+}
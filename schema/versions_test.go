@@ -0,0 +1,87 @@
+/*
+   Copyright © The CDI Authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/mod/semver"
+
+	"tags.cncf.io/container-device-interface/pkg/cdi"
+)
+
+// TestFieldVersions checks that the versions introducing fields agree
+// with the table of Spec features of the cdi package.
+func TestFieldVersions(t *testing.T) {
+	// the Spec features which are fields, the others are not in the schema
+	fieldFeatures := map[string]string{
+		cdi.FeatureMountType:          "Mount.type",
+		cdi.FeatureDeviceNodeHostPath: "DeviceNode.hostPath",
+		cdi.FeatureSpecAnnotations:    "Spec.annotations",
+		cdi.FeatureDeviceAnnotations:  "Device.annotations",
+	}
+
+	fields := map[string]string{}
+	for _, f := range cdi.Features() {
+		if field, ok := fieldFeatures[f.Name]; ok {
+			fields[field] = f.Version
+		}
+	}
+	require.Len(t, fields, len(fieldFeatures), "field features missing from the feature table")
+	require.Equal(t, fields, fieldVersions)
+}
+
+// TestNamePatterns checks that the versions introducing name patterns
+// agree with the table of Spec features of the cdi package.
+func TestNamePatterns(t *testing.T) {
+	// the Spec features which relax name rules
+	nameFeatures := map[string]string{
+		cdi.FeatureDeviceNameNonLetterStart: "Device.name",
+		cdi.FeatureClassNameDots:            "Spec.kind",
+	}
+
+	names := map[string][]string{}
+	for _, f := range cdi.Features() {
+		if field, ok := nameFeatures[f.Name]; ok {
+			names[field] = append(names[field], f.Version)
+		}
+	}
+	require.Len(t, names, len(nameFeatures), "name features missing from the feature table")
+
+	patterns := map[string][]string{}
+	for field, versioned := range namePatterns {
+		require.Equal(t, specVersions[0], versioned[0].version,
+			"field %s has no pattern for the earliest version", field)
+		for _, p := range versioned[1:] {
+			patterns[field] = append(patterns[field], p.version)
+		}
+	}
+	require.Equal(t, names, patterns)
+}
+
+// TestSpecVersions checks that there is a schema for every Spec version
+// since the earliest one with a schema.
+func TestSpecVersions(t *testing.T) {
+	var expected []string
+	for _, v := range cdi.SpecVersions() {
+		if semver.Compare("v"+v, "v"+specVersions[0]) >= 0 {
+			expected = append(expected, v)
+		}
+	}
+	require.Equal(t, expected, specVersions)
+}